@@ -0,0 +1,11 @@
+// Show the executed command line and its output under the message
+function formatOutput(result) {
+	let message = result.message;
+	if (result.command) {
+		message += '\n$ ' + result.command + '\n' + result.output;
+	}
+	return message;
+}
+
+bindForm('unsafeForm', 'unsafe/diagnose', 'unsafeResult', formatOutput);
+bindForm('safeForm', 'safe/diagnose', 'safeResult', formatOutput);
@@ -0,0 +1,209 @@
+package cmdinject
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/config"
+	"shared/headers"
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tool describes a diagnostics command and its fixed arguments
+type Tool struct {
+	Name string
+	Args []string
+}
+
+// tools is the list of diagnostics the page offers
+var tools = map[string]Tool{
+	"ping":     {Name: "ping", Args: []string{"-c", "2"}},
+	"nslookup": {Name: "nslookup"},
+}
+
+// hostnamePattern allows RFC 1123 hostnames only
+var hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+//go:embed templates
+var templates embed.FS
+
+//go:embed assets
+var assets embed.FS
+
+// pages are the html/template pages wrapped in the shared layout
+var pages = layout.MustParse(templates, map[string]string{
+	"index": "templates/index.html",
+})
+
+//go:embed bin
+var stubs embed.FS
+
+var (
+	binDir     string // directory the offline stub binaries are installed in
+	binDirErr  error
+	installBin sync.Once
+)
+
+const commandTimeout = 10 * time.Second
+
+// Unsafe diagnostics - vulnerable to command injection
+func unsafeDiagnose(c *gin.Context) {
+	tool, ok := tools[c.PostForm("tool")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown tool"})
+		return
+	}
+	host := c.PostForm("host")
+
+	dir, err := stubDir()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Stub binaries unavailable: %v", err)})
+		return
+	}
+
+	// Dangerous: building a shell command line from user input
+	parts := append(append([]string{tool.Name}, tool.Args...), host)
+	command := strings.Join(parts, " ")
+	log.Printf("Executing: sh -c %q", command)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	// Resolve ping/nslookup to the stubs so the lab works offline
+	cmd.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	output, err := cmd.CombinedOutput()
+
+	respond(c, command, output, err)
+}
+
+// Safe diagnostics - allow-listed input and no shell
+func safeDiagnose(c *gin.Context) {
+	tool, ok := tools[c.PostForm("tool")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown tool"})
+		return
+	}
+	host := c.PostForm("host")
+
+	if err := validateHost(host); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Invalid host: %v", err),
+		})
+		return
+	}
+
+	dir, err := stubDir()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Stub binaries unavailable: %v", err)})
+		return
+	}
+
+	// Safe: the host is passed as a single argv entry after "--",
+	// so shell metacharacters and leading dashes have no special meaning
+	args := append(append([]string{}, tool.Args...), "--", host)
+	log.Printf("Executing: %s %q", tool.Name, args)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, filepath.Join(dir, tool.Name), args...)
+	output, err := cmd.CombinedOutput()
+
+	respond(c, tool.Name+" "+strings.Join(args, " "), output, err)
+}
+
+// stubDir writes the embedded stub binaries to a temporary directory the
+// first time they are needed, so the lab works from any working directory
+func stubDir() (string, error) {
+	installBin.Do(func() {
+		binDir, binDirErr = os.MkdirTemp("", "cmd_inject_bin")
+		if binDirErr != nil {
+			return
+		}
+		entries, err := stubs.ReadDir("bin")
+		if err != nil {
+			binDirErr = err
+			return
+		}
+		for _, entry := range entries {
+			data, err := stubs.ReadFile("bin/" + entry.Name())
+			if err != nil {
+				binDirErr = err
+				return
+			}
+			if err := os.WriteFile(filepath.Join(binDir, entry.Name()), data, 0o755); err != nil {
+				binDirErr = err
+				return
+			}
+		}
+	})
+	return binDir, binDirErr
+}
+
+// validateHost accepts an IP address or an RFC 1123 hostname
+func validateHost(host string) error {
+	if host == "" {
+		return errors.New("host is required")
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if len(host) > 253 || !hostnamePattern.MatchString(host) {
+		return errors.New("only IP addresses and hostnames are allowed")
+	}
+	return nil
+}
+
+// respond writes the command result as JSON
+func respond(c *gin.Context, command string, output []byte, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": fmt.Sprintf("Command failed: %v", err),
+			"command": command,
+			"output":  string(output),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Command executed",
+		"command": command,
+		"output":  string(output),
+	})
+}
+
+// RegisterRoutes mounts the command injection lab on rg, registering only
+// the endpoints enabled in cfg
+func RegisterRoutes(rg *gin.RouterGroup, cfg config.Lab) {
+	routes := cfg.Routes(rg)
+
+	// Serve the shared layout assets, the security headers settings
+	// and this lab's scripts
+	layout.Mount(rg)
+	headers.Mount(rg)
+	assetFS, _ := fs.Sub(assets, "assets")
+	rg.StaticFS("/assets", http.FS(assetFS))
+
+	routes.Unsafe(http.MethodPost, "/unsafe/diagnose", unsafeDiagnose)
+	routes.Safe(http.MethodPost, "/safe/diagnose", safeDiagnose)
+
+	// Provide a simple frontend page
+	rg.GET("/", func(c *gin.Context) {
+		pages.Render(c, http.StatusOK, "index", gin.H{"Routes": routes})
+	})
+}
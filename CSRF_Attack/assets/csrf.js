@@ -0,0 +1,36 @@
+// Add CSRF token to safe form submissions
+document.getElementById('safeForm')?.addEventListener('submit', function(e) {
+	e.preventDefault();
+	fetch('transfer/safe', {
+		method: 'POST',
+		headers: {
+			'X-CSRF-Token': this.elements['_csrf'].value
+		},
+		body: new FormData(this)
+	})
+	.then(response => response.json())
+	.then(data => alert(data.message || data.error))
+	.catch(error => alert('Error: ' + error));
+});
+
+// Simulate CSRF attack
+function simulateAttack() {
+	var form = document.createElement('form');
+	form.method = 'POST';
+	form.action = 'transfer/unsafe';
+
+	var to = document.createElement('input');
+	to.type = 'hidden';
+	to.name = 'to';
+	to.value = 'attacker';
+	form.appendChild(to);
+
+	var amount = document.createElement('input');
+	amount.type = 'hidden';
+	amount.name = 'amount';
+	amount.value = '500';
+	form.appendChild(amount);
+
+	document.body.appendChild(form);
+	form.submit();
+}
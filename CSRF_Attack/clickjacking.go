@@ -0,0 +1,140 @@
+package csrfattack
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"shared/config"
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// frameOption is a choice of framing defence for the bank's pages
+type frameOption struct {
+	Name   string
+	Title  string
+	Header string // empty when nothing is sent
+	Value  string
+}
+
+var frameOptions = []frameOption{
+	{Name: "none", Title: "None (any site can frame the bank)"},
+	{Name: "xfo-deny", Title: "X-Frame-Options: DENY", Header: "X-Frame-Options", Value: "DENY"},
+	{Name: "xfo-sameorigin", Title: "X-Frame-Options: SAMEORIGIN", Header: "X-Frame-Options", Value: "SAMEORIGIN"},
+	{Name: "frame-ancestors-none", Title: "Content-Security-Policy: frame-ancestors 'none'", Header: "Content-Security-Policy", Value: "frame-ancestors 'none'"},
+	{Name: "frame-ancestors-self", Title: "Content-Security-Policy: frame-ancestors 'self'", Header: "Content-Security-Policy", Value: "frame-ancestors 'self'"},
+}
+
+// frameCookie holds the selected frameOption name
+const frameCookie = "frame_protection"
+
+// currentFrameOption returns the defence selected in the browser, or none
+func currentFrameOption(c *gin.Context) frameOption {
+	name, _ := c.Cookie(frameCookie)
+	for _, o := range frameOptions {
+		if o.Name == name {
+			return o
+		}
+	}
+	return frameOptions[0]
+}
+
+// frameProtection sends the selected framing defence. Labs without
+// vulnerable endpoints always send both headers.
+func frameProtection(vulnerable bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !vulnerable {
+			c.Header("X-Frame-Options", "DENY")
+			c.Writer.Header().Add("Content-Security-Policy", "frame-ancestors 'none'")
+			c.Next()
+			return
+		}
+		switch o := currentFrameOption(c); o.Header {
+		case "":
+			// Unsafe: nothing stops another site from framing the page
+		case "Content-Security-Policy":
+			// Added, so a policy from the security headers page still applies
+			c.Writer.Header().Add(o.Header, o.Value)
+		default:
+			c.Header(o.Header, o.Value)
+		}
+		c.Next()
+	}
+}
+
+func selectFrameProtection(c *gin.Context) {
+	name := c.PostForm("protection")
+	for _, o := range frameOptions {
+		if o.Name == name {
+			c.SetCookie(frameCookie, name, 0, layout.BasePath(c), "", false, true)
+			break
+		}
+	}
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+// transferPage is a standalone transfer form, the page the attacker frames.
+// Like many real forms it can be prefilled from the query string.
+func transferPage(routes *config.Routes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("currentUser") == "" {
+			// Like a real bank, send visitors who are not logged in to the
+			// login form
+			c.Redirect(http.StatusFound, layout.BasePath(c))
+			return
+		}
+		token, err := issueToken(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CSRF token"})
+			return
+		}
+		pages.Render(c, http.StatusOK, "transfer", gin.H{
+			"To":     c.DefaultQuery("to", "bob"),
+			"Amount": c.DefaultQuery("amount", "100"),
+			"Token":  token,
+			"Routes": routes,
+		})
+	}
+}
+
+// attackerOrigin is the URL of the attacker server listening on addr, on
+// the host the browser used to reach the bank
+func attackerOrigin(c *gin.Context, addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = c.Request.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// RegisterAttackerRoutes mounts the clickjacking page on rg. It runs on
+// its own port, so it is a different origin from the bank, like a real
+// attacker's site.
+func RegisterAttackerRoutes(rg *gin.RouterGroup) {
+	layout.Mount(rg)
+
+	rg.GET("/", func(c *gin.Context) {
+		// The bank page links here with its own URL as the target
+		target := c.DefaultQuery("target", "http://localhost:8080/")
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "target must be an http or https URL"})
+			return
+		}
+		if !strings.HasSuffix(target, "/") {
+			target += "/"
+		}
+		pages.Render(c, http.StatusOK, "clickjack", gin.H{
+			"Frame": target + "transfer?to=attacker&amount=500",
+		})
+	})
+}
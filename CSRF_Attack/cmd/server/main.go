@@ -0,0 +1,52 @@
+// Command server runs the CSRF lab on its own.
+package main
+
+import (
+	"log"
+
+	csrfattack "csrf_demo"
+	"shared/config"
+	"shared/guard"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	lab := cfg.Labs[config.CSRF]
+
+	// Connect to SQLite database
+	db, err := gorm.Open(sqlite.Open(lab.DB), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := csrfattack.Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	r, err := guard.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	csrfattack.RegisterRoutes(&r.RouterGroup, db, lab)
+
+	// The clickjacking page runs on its own port, a separate origin
+	if lab.Attacker != "" && lab.Vulnerable() {
+		attacker, err := guard.New(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		csrfattack.RegisterAttackerRoutes(&attacker.RouterGroup)
+		go func() {
+			log.Fatal(guard.Run(attacker, lab.Attacker, cfg))
+		}()
+	}
+
+	if err := guard.Run(r, lab.Addr, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
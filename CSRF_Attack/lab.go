@@ -0,0 +1,355 @@
+package csrfattack
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"shared/config"
+	"shared/headers"
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// User represents a user in the system
+type User struct {
+	ID           uint   `gorm:"primarykey"`
+	Username     string `gorm:"unique"`
+	PasswordHash string // bcrypt hash of the login password
+	Balance      int    // User's account balance
+}
+
+// Transfer represents a money transfer
+type Transfer struct {
+	ID          uint
+	FromUserID  uint
+	ToUserID    uint
+	Amount      int
+	Description string
+}
+
+//go:embed templates
+var templates embed.FS
+
+//go:embed assets
+var assets embed.FS
+
+// pages are the html/template pages wrapped in the shared layout
+var pages = layout.MustParse(templates, map[string]string{
+	"index":     "templates/index.html",
+	"transfer":  "templates/transfer.html",
+	"clickjack": "templates/clickjack.html",
+})
+
+var (
+	db         *gorm.DB
+	csrfTokens sync.Map // session id -> CSRF token
+)
+
+// seedUsers are the demo accounts and their passwords
+var seedUsers = []struct {
+	Username string
+	Password string
+	Balance  int
+}{
+	{"alice", "alice123", 1000},
+	{"bob", "bob123", 1000},
+	// The account the attack demos send money to
+	{"attacker", "attacker123", 0},
+}
+
+// generateCSRFToken generates a new CSRF token
+func generateCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %v", err)
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// issueToken generates a CSRF token for the current session and stores it
+func issueToken(c *gin.Context) (string, error) {
+	token, err := generateCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	csrfTokens.Store(c.GetString("sessionID"), token)
+	return token, nil
+}
+
+// Migrate creates the schema and seeds the demo accounts
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&User{}, &Transfer{}); err != nil {
+		return err
+	}
+
+	// Initialize the users if they don't exist, and give accounts from
+	// databases created before logins a password
+	for _, seed := range seedUsers {
+		var user User
+		if err := db.Where(User{Username: seed.Username}).Attrs(User{Balance: seed.Balance}).FirstOrCreate(&user).Error; err != nil {
+			return err
+		}
+		if user.PasswordHash != "" {
+			continue
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(seed.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&user).Update("password_hash", string(hash)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterRoutes mounts the CSRF lab on rg, registering only the endpoints
+// enabled in cfg
+func RegisterRoutes(rg *gin.RouterGroup, database *gorm.DB, cfg config.Lab) {
+	db = database
+	routes := cfg.Routes(rg)
+
+	// Serve the shared layout assets, the security headers settings
+	// and this lab's scripts
+	layout.Mount(rg)
+	headers.Mount(rg)
+	rg.Use(frameProtection(cfg.Vulnerable()), authenticate)
+	assetFS, _ := fs.Sub(assets, "assets")
+	rg.StaticFS("/assets", http.FS(assetFS))
+
+	// Serve static files
+	rg.Static("/static", "./static")
+
+	// Vulnerable transfer endpoint (no CSRF protection)
+	routes.Unsafe(http.MethodPost, "/transfer/unsafe", requireLogin, func(c *gin.Context) {
+		toUsername := c.PostForm("to")
+		amountStr := c.PostForm("amount")
+		amount, err := strconv.Atoi(amountStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+			return
+		}
+
+		// Validate amount
+		if amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be positive"})
+			return
+		}
+
+		// The sender is whoever the session cookie belongs to, even when
+		// another site made the browser send the request
+		fromUsername := c.GetString("currentUser")
+
+		var fromUser, toUser User
+		if err := db.Where("username = ?", fromUsername).First(&fromUser).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sender"})
+			return
+		}
+		if err := db.Where("username = ?", toUsername).First(&toUser).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipient"})
+			return
+		}
+
+		// Check balance
+		if fromUser.Balance < amount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+			return
+		}
+
+		// Begin transaction
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Update balances
+		if err := tx.Model(&fromUser).Update("balance", fromUser.Balance-amount).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+			return
+		}
+
+		if err := tx.Model(&toUser).Update("balance", toUser.Balance+amount).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+			return
+		}
+
+		// Create transfer record
+		if err := tx.Create(&Transfer{
+			FromUserID:  fromUser.ID,
+			ToUserID:    toUser.ID,
+			Amount:      amount,
+			Description: "Transfer from " + fromUsername + " to " + toUsername,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+			return
+		}
+
+		// Commit transaction
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Transfer successful",
+			"from": gin.H{
+				"username": fromUser.Username,
+				"balance":  fromUser.Balance,
+			},
+			"to": gin.H{
+				"username": toUser.Username,
+				"balance":  toUser.Balance,
+			},
+			"amount": amount,
+		})
+	})
+
+	// Safe transfer endpoint (with CSRF protection)
+	routes.Safe(http.MethodPost, "/transfer/safe", requireLogin, func(c *gin.Context) {
+		// Verify CSRF token
+		token := c.GetHeader("X-CSRF-Token")
+		expectedToken, exists := csrfTokens.Load(c.GetString("sessionID"))
+		if !exists || token != expectedToken.(string) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+			return
+		}
+
+		toUsername := c.PostForm("to")
+		amountStr := c.PostForm("amount")
+
+		// Convert amount string to integer
+		amount, err := strconv.Atoi(amountStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+			return
+		}
+
+		// Validate amount
+		if amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be positive"})
+			return
+		}
+
+		fromUsername := c.GetString("currentUser")
+
+		var fromUser, toUser User
+		if err := db.Where("username = ?", fromUsername).First(&fromUser).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sender"})
+			return
+		}
+		if err := db.Where("username = ?", toUsername).First(&toUser).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipient"})
+			return
+		}
+
+		// Check balance
+		if fromUser.Balance < amount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+			return
+		}
+
+		// Begin transaction
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Update balances
+		if err := tx.Model(&fromUser).Update("balance", fromUser.Balance-amount).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+			return
+		}
+
+		if err := tx.Model(&toUser).Update("balance", toUser.Balance+amount).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+			return
+		}
+
+		// Create transfer record
+		if err := tx.Create(&Transfer{
+			FromUserID:  fromUser.ID,
+			ToUserID:    toUser.ID,
+			Amount:      amount,
+			Description: "Safe transfer from " + fromUsername + " to " + toUsername,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+			return
+		}
+
+		// Commit transaction
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Safe transfer successful",
+			"from": gin.H{
+				"username": fromUser.Username,
+				"balance":  fromUser.Balance,
+			},
+			"to": gin.H{
+				"username": toUser.Username,
+				"balance":  toUser.Balance,
+			},
+			"amount": amount,
+		})
+	})
+
+	// Main page
+	rg.GET("/", func(c *gin.Context) {
+		var users []User
+		db.Find(&users)
+
+		data := gin.H{
+			"Users":           users,
+			"Routes":          routes,
+			"FrameOptions":    frameOptions,
+			"FrameProtection": currentFrameOption(c).Name,
+			"Self":            "http://" + c.Request.Host + layout.BasePath(c),
+		}
+		if cfg.Attacker != "" && cfg.Vulnerable() {
+			data["Attacker"] = attackerOrigin(c, cfg.Attacker)
+		}
+		if username := c.GetString("currentUser"); username != "" {
+			// Generate CSRF token for the current session
+			token, err := issueToken(c)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CSRF token"})
+				return
+			}
+			var user User
+			db.Where("username = ?", username).First(&user)
+			data["Token"] = token
+			data["User"] = user
+		}
+		pages.Render(c, http.StatusOK, "index", data)
+	})
+
+	rg.POST("/login", login)
+	rg.POST("/logout", logout)
+
+	// Clickjacking target and framing defences
+	rg.GET("/transfer", transferPage(routes))
+	rg.POST("/frame-protection", selectFrameProtection)
+}
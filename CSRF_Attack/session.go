@@ -0,0 +1,82 @@
+package csrfattack
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sessionCookie identifies the logged-in bank customer. The browser sends
+// it with every request to the bank, including forged ones.
+const sessionCookie = "bank_session"
+
+var sessions sync.Map // session id -> username
+
+// newSession logs user in and returns the session id
+func newSession(user string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	sessions.Store(id, user)
+	return id
+}
+
+// authenticate sets currentUser and sessionID for requests with a valid
+// session cookie
+func authenticate(c *gin.Context) {
+	if id, err := c.Cookie(sessionCookie); err == nil {
+		if user, ok := sessions.Load(id); ok {
+			c.Set("currentUser", user.(string))
+			c.Set("sessionID", id)
+		}
+	}
+	c.Next()
+}
+
+// requireLogin rejects requests without a session
+func requireLogin(c *gin.Context) {
+	if c.GetString("currentUser") == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not logged in"})
+		return
+	}
+	c.Next()
+}
+
+func login(c *gin.Context) {
+	var user User
+	err := db.Where("username = ?", c.PostForm("username")).First(&user).Error
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(c.PostForm("password"))) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		return
+	}
+
+	// SameSite=Lax keeps the cookie off cross-site POSTs, but the attacker
+	// pages run on another port of the same host, which is the same site
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    newSession(user.Username),
+		Path:     layout.BasePath(c),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+func logout(c *gin.Context) {
+	if id := c.GetString("sessionID"); id != "" {
+		sessions.Delete(id)
+		csrfTokens.Delete(id)
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:   sessionCookie,
+		Path:   layout.BasePath(c),
+		MaxAge: -1,
+	})
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
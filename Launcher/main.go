@@ -0,0 +1,136 @@
+// Command launcher runs every lab on one server, each under its own prefix,
+// with a landing page linking to them.
+package main
+
+import (
+	"context"
+	"embed"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	cmdinject "cmd_inject_demo"
+	csrfattack "csrf_demo"
+	nosqlinject "nosql_inject_demo"
+	"shared/config"
+	"shared/guard"
+	"shared/headers"
+	"shared/layout"
+	sqlinject "sql_inject_demo"
+	sstiinject "ssti_demo"
+	xssinject "xss_demo"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Lab describes one entry on the landing page
+type Lab struct {
+	Key         string // name in config.Labs
+	Name        string
+	Path        string
+	Description string
+	Mode        config.Mode
+}
+
+var labs = []Lab{
+	{Key: config.SQLi, Name: "SQL Injection", Path: "sqli/", Description: "SQL, XPath and LDAP login bypasses"},
+	{Key: config.XSS, Name: "XSS", Path: "xss/", Description: "Reflected, stored and DOM-based cross-site scripting"},
+	{Key: config.CSRF, Name: "CSRF", Path: "csrf/", Description: "Cross-site request forgery against a transfer form"},
+	{Key: config.NoSQL, Name: "NoSQL Injection", Path: "nosql/", Description: "Operator injection against a document store"},
+	{Key: config.CMDi, Name: "Command Injection", Path: "cmdi/", Description: "Shell injection in network diagnostics"},
+	{Key: config.SSTI, Name: "Template Injection", Path: "ssti/", Description: "User-supplied Go templates in a custom greeting"},
+}
+
+//go:embed templates
+var templates embed.FS
+
+var pages = layout.MustParse(templates, map[string]string{
+	"index": "templates/index.html",
+})
+
+// openDB connects to a lab's SQLite database and runs its migration
+func openDB(path string, migrate func(*gorm.DB) error) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+	return db
+}
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqli := cfg.Labs[config.SQLi]
+	xss := cfg.Labs[config.XSS]
+	csrf := cfg.Labs[config.CSRF]
+
+	// Stop the server and the XSS admin bot on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sqliDB := openDB(sqli.DB, sqlinject.Migrate)
+	xssDB := openDB(xss.DB, xssinject.Migrate)
+	csrfDB := openDB(csrf.DB, csrfattack.Migrate)
+
+	for i := range labs {
+		labs[i].Mode = cfg.Labs[labs[i].Key].Mode
+	}
+
+	r, err := guard.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sqlinject.RegisterRoutes(r.Group("/sqli"), sqliDB, sqli)
+	xssinject.RegisterRoutes(r.Group("/xss"), xssDB, xss)
+	csrfattack.RegisterRoutes(r.Group("/csrf"), csrfDB, csrf)
+	nosqlinject.RegisterRoutes(r.Group("/nosql"), cfg.Labs[config.NoSQL])
+	cmdinject.RegisterRoutes(r.Group("/cmdi"), cfg.Labs[config.CMDi])
+	sstiinject.RegisterRoutes(r.Group("/ssti"), cfg.Labs[config.SSTI])
+
+	// Landing page
+	home := r.Group("/")
+	layout.Mount(home)
+	headers.Mount(home)
+	home.GET("/", func(c *gin.Context) {
+		pages.Render(c, http.StatusOK, "index", gin.H{"Labs": labs})
+	})
+
+	// The attacker collector runs on its own port, a separate origin
+	if xss.Attacker != "" && xss.Vulnerable() {
+		attacker, err := guard.New(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		xssinject.RegisterAttackerRoutes(&attacker.RouterGroup)
+		go func() {
+			log.Fatal(guard.Run(attacker, xss.Attacker, cfg))
+		}()
+		go xssinject.RunAdminBot(ctx)
+	}
+
+	// So does the clickjacking page
+	if csrf.Attacker != "" && csrf.Vulnerable() {
+		attacker, err := guard.New(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		csrfattack.RegisterAttackerRoutes(&attacker.RouterGroup)
+		go func() {
+			log.Fatal(guard.Run(attacker, csrf.Attacker, cfg))
+		}()
+	}
+
+	if err := guard.RunContext(ctx, r, cfg.Addr, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
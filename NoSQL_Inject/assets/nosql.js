@@ -0,0 +1,28 @@
+// bindRawForm sends the textarea contents as-is with the chosen Content-Type
+function bindRawForm(formId, url, resultId) {
+	const form = document.getElementById(formId);
+	if (!form) {
+		return;
+	}
+	form.onsubmit = async (e) => {
+		e.preventDefault();
+		try {
+			const response = await fetch(url, {
+				method: 'POST',
+				headers: { 'Content-Type': form.type.value },
+				body: form.body.value
+			});
+			const result = await response.json();
+			let message = result.message;
+			if (result.user) {
+				message += ' as ' + result.user.username + ' (' + result.user.role + ')';
+			}
+			showResult(resultId, response.ok, message);
+		} catch (error) {
+			showResult(resultId, false, 'Request failed: ' + error.message);
+		}
+	};
+}
+
+bindRawForm('unsafeForm', 'unsafe/login', 'unsafeResult');
+bindRawForm('safeForm', 'safe/login', 'safeResult');
@@ -0,0 +1,28 @@
+// Command server runs the NoSQL injection lab on its own.
+package main
+
+import (
+	"log"
+
+	nosqlinject "nosql_inject_demo"
+	"shared/config"
+	"shared/guard"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	lab := cfg.Labs[config.NoSQL]
+
+	r, err := guard.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	nosqlinject.RegisterRoutes(&r.RouterGroup, lab)
+
+	if err := guard.Run(r, lab.Addr, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
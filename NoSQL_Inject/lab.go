@@ -0,0 +1,200 @@
+package nosqlinject
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+
+	"shared/config"
+	"shared/headers"
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginRequest is the typed shape of a login body.
+// Binding into concrete string fields means an operator document like
+// {"$ne": null} can never reach the query.
+type LoginRequest struct {
+	Username string `json:"username" form:"username" binding:"required"`
+	Password string `json:"password" form:"password" binding:"required"`
+}
+
+//go:embed templates
+var templates embed.FS
+
+//go:embed assets
+var assets embed.FS
+
+// pages are the html/template pages wrapped in the shared layout
+var pages = layout.MustParse(templates, map[string]string{
+	"index": "templates/index.html",
+})
+
+var users *Collection
+
+// Unsafe login method - vulnerable to NoSQL operator injection
+func unsafeLogin(c *gin.Context) {
+	// Dangerous: the request body is decoded into a generic map and the
+	// values are used as query conditions without checking their type
+	body, err := bindLooseBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Login failed with error: %v", err),
+		})
+		return
+	}
+
+	filter := map[string]interface{}{
+		"username": body["username"],
+		"password": body["password"],
+	}
+
+	// Log the query for demonstration
+	query, _ := json.Marshal(filter)
+	log.Printf("Executing query: users.findOne(%s)", query)
+
+	user, err := users.FindOne(filter)
+	if err != nil {
+		// Return error message for error-based injection demonstration
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Login failed with error: %v", err),
+		})
+		return
+	}
+
+	if user != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful",
+			"user":    user,
+		})
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login failed: Invalid credentials",
+		})
+	}
+}
+
+// Safe login method - using typed binding and explicit $eq
+func safeLogin(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Login failed: username and password must be strings",
+		})
+		return
+	}
+
+	user, err := users.FindOne(map[string]interface{}{
+		"username": map[string]interface{}{"$eq": req.Username},
+		"password": map[string]interface{}{"$eq": req.Password},
+	})
+
+	if err == nil && user != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful",
+			"user":    user,
+		})
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login failed: Invalid credentials",
+		})
+	}
+}
+
+// bindLooseBody decodes a JSON or form body into a generic map.
+// Form keys use the bracket syntax popular with qs/PHP style parsers, so
+// username[$ne]=x becomes {"username": {"$ne": "x"}} and
+// username[$in][]=a&username[$in][]=b becomes {"username": {"$in": ["a", "b"]}}.
+func bindLooseBody(c *gin.Context) (map[string]interface{}, error) {
+	body := map[string]interface{}{}
+
+	if strings.HasPrefix(c.ContentType(), "application/json") {
+		if err := json.NewDecoder(c.Request.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		return body, nil
+	}
+
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return nil, err
+	}
+	for key, values := range c.Request.PostForm {
+		field, path := splitBracketKey(key)
+		if len(path) == 0 {
+			body[field] = values[0]
+			continue
+		}
+
+		// Only one level of nesting is needed for operator documents
+		ops, ok := body[field].(map[string]interface{})
+		if !ok {
+			ops = map[string]interface{}{}
+			body[field] = ops
+		}
+		if len(path) > 1 && path[1] == "" {
+			list := make([]interface{}, len(values))
+			for i, v := range values {
+				list[i] = v
+			}
+			ops[path[0]] = list
+		} else {
+			ops[path[0]] = values[0]
+		}
+	}
+	return body, nil
+}
+
+// splitBracketKey splits "a[b][]" into "a" and ["b", ""]
+func splitBracketKey(key string) (string, []string) {
+	open := strings.Index(key, "[")
+	if open < 0 || !strings.HasSuffix(key, "]") {
+		return key, nil
+	}
+	field := key[:open]
+	inner := strings.TrimSuffix(key[open+1:], "]")
+	return field, strings.Split(inner, "][")
+}
+
+// RegisterRoutes seeds the user collection and mounts the NoSQL
+// injection lab on rg, registering only the endpoints enabled in cfg
+func RegisterRoutes(rg *gin.RouterGroup, cfg config.Lab) {
+	routes := cfg.Routes(rg)
+	users = NewCollection()
+
+	// Create test users
+	users.Insert(Document{
+		"username": "admin",
+		"password": "123456",
+		"role":     "admin",
+	})
+	users.Insert(Document{
+		"username": "user1",
+		"password": "password1",
+		"role":     "user",
+	})
+	users.Insert(Document{
+		"username": "user2",
+		"password": "password2",
+		"role":     "user",
+	})
+
+	// Serve the shared layout assets, the security headers settings
+	// and this lab's scripts
+	layout.Mount(rg)
+	headers.Mount(rg)
+	assetFS, _ := fs.Sub(assets, "assets")
+	rg.StaticFS("/assets", http.FS(assetFS))
+
+	routes.Unsafe(http.MethodPost, "/unsafe/login", unsafeLogin)
+	routes.Safe(http.MethodPost, "/safe/login", safeLogin)
+
+	// Provide a simple frontend page
+	rg.GET("/", func(c *gin.Context) {
+		pages.Render(c, http.StatusOK, "index", gin.H{"Routes": routes})
+	})
+}
@@ -0,0 +1,239 @@
+package nosqlinject
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Document is a schemaless record, similar to a BSON document
+type Document map[string]interface{}
+
+// Collection is a minimal in-process document store.
+// Its query language mimics MongoDB: a filter is a map of field names to
+// either a literal value (implicit $eq) or an operator document such as
+// {"$ne": null}.
+type Collection struct {
+	mu   sync.RWMutex
+	docs []Document
+}
+
+// NewCollection creates an empty collection
+func NewCollection() *Collection {
+	return &Collection{}
+}
+
+// Insert stores a copy of the document
+func (c *Collection) Insert(doc Document) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored := make(Document, len(doc))
+	for k, v := range doc {
+		stored[k] = v
+	}
+	c.docs = append(c.docs, stored)
+}
+
+// FindOne returns the first document matching the filter, or nil
+func (c *Collection) FindOne(filter map[string]interface{}) (Document, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, doc := range c.docs {
+		ok, err := matchDocument(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return doc, nil
+		}
+	}
+	return nil, nil
+}
+
+// matchDocument reports whether doc satisfies every condition in filter
+func matchDocument(doc Document, filter map[string]interface{}) (bool, error) {
+	for key, cond := range filter {
+		switch key {
+		case "$or", "$and":
+			clauses, ok := cond.([]interface{})
+			if !ok {
+				return false, fmt.Errorf("%s expects an array", key)
+			}
+			matched, err := matchLogical(doc, key, clauses)
+			if err != nil || !matched {
+				return false, err
+			}
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("unknown top level operator: %s", key)
+			}
+			value, exists := doc[key]
+			matched, err := matchField(value, exists, cond)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// matchLogical evaluates $or / $and clauses
+func matchLogical(doc Document, op string, clauses []interface{}) (bool, error) {
+	for _, clause := range clauses {
+		sub, ok := clause.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s clauses must be documents", op)
+		}
+		matched, err := matchDocument(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		if op == "$or" && matched {
+			return true, nil
+		}
+		if op == "$and" && !matched {
+			return false, nil
+		}
+	}
+	return op == "$and", nil
+}
+
+// matchField checks a single field value against a condition.
+// A condition that is itself a document of $-prefixed keys is treated as
+// a set of operators - this is exactly what makes operator injection work.
+func matchField(value interface{}, exists bool, cond interface{}) (bool, error) {
+	ops, ok := cond.(map[string]interface{})
+	if !ok || !isOperatorDocument(ops) {
+		return exists && equal(value, cond), nil
+	}
+
+	for op, arg := range ops {
+		var matched bool
+		switch op {
+		case "$eq":
+			matched = exists && equal(value, arg)
+		case "$ne":
+			matched = !exists && arg != nil || exists && !equal(value, arg)
+		case "$gt", "$gte", "$lt", "$lte":
+			if !exists {
+				break
+			}
+			cmp, comparable := compare(value, arg)
+			if !comparable {
+				break
+			}
+			switch op {
+			case "$gt":
+				matched = cmp > 0
+			case "$gte":
+				matched = cmp >= 0
+			case "$lt":
+				matched = cmp < 0
+			case "$lte":
+				matched = cmp <= 0
+			}
+		case "$in", "$nin":
+			list, ok := arg.([]interface{})
+			if !ok {
+				return false, fmt.Errorf("%s needs an array", op)
+			}
+			found := false
+			for _, item := range list {
+				if exists && equal(value, item) {
+					found = true
+					break
+				}
+			}
+			matched = found == (op == "$in")
+		case "$exists":
+			matched = exists == truthy(arg)
+		case "$regex":
+			pattern, ok := arg.(string)
+			if !ok {
+				return false, fmt.Errorf("$regex has to be a string")
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, fmt.Errorf("invalid $regex: %v", err)
+			}
+			s, isString := value.(string)
+			matched = exists && isString && re.MatchString(s)
+		default:
+			return false, fmt.Errorf("unknown operator: %s", op)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isOperatorDocument reports whether every key of m starts with "$"
+func isOperatorDocument(m map[string]interface{}) bool {
+	if len(m) == 0 {
+		return false
+	}
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compares two values, treating all numeric types alike
+func equal(a, b interface{}) bool {
+	if cmp, ok := compare(a, b); ok {
+		return cmp == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare orders two scalar values; ok is false if they are not comparable
+func compare(a, b interface{}) (int, bool) {
+	if af, aok := toFloat(a); aok {
+		if bf, bok := toFloat(b); bok {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		return strings.Compare(as, bs), true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case nil:
+		return false
+	case string:
+		return b != "" && b != "0" && b != "false"
+	case float64:
+		return b != 0
+	}
+	return true
+}
@@ -0,0 +1,6 @@
+bindForm('unsafeForm', 'unsafe/login', 'unsafeResult');
+bindForm('safeForm', 'safe/login', 'safeResult');
+bindForm('unsafeXPathForm', 'unsafe/xpath-login', 'unsafeXPathResult');
+bindForm('safeXPathForm', 'safe/xpath-login', 'safeXPathResult');
+bindForm('unsafeLDAPForm', 'unsafe/ldap-login', 'unsafeLDAPResult');
+bindForm('safeLDAPForm', 'safe/ldap-login', 'safeLDAPResult');
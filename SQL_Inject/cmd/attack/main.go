@@ -0,0 +1,106 @@
+// Command attack replays the payload corpus against a running SQL_Inject
+// server and checks every response against the expected outcome. It uses
+// the corpus built into the server unless -corpus names another file.
+//
+//	go run ./cmd/attack -base http://localhost:8080
+//	go run ./cmd/attack -base http://localhost:8080/sqli   # under the launcher
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"sql_inject_demo/payloads"
+)
+
+func main() {
+	corpusPath := flag.String("corpus", "", "path to a payload corpus instead of the built-in one")
+	base := flag.String("base", "http://localhost:8080", "base URL of the running demo, http://localhost:8080/sqli under the launcher")
+	category := flag.String("category", "", "only run payloads in this category")
+	flag.Parse()
+
+	corpus, err := payloads.Default()
+	if *corpusPath != "" {
+		corpus, err = payloads.Load(*corpusPath)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Endpoints start with a slash
+	*base = strings.TrimSuffix(*base, "/")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tENDPOINT\tEXPECTED\tGOT\tTIME\tRESULT")
+
+	failures := 0
+	for _, p := range corpus.Payloads {
+		if *category != "" && p.Category != *category {
+			continue
+		}
+
+		checks := []struct {
+			endpoint string
+			expected payloads.Outcome
+			minDelay time.Duration
+		}{
+			{p.Target, p.Expect.Unsafe, p.Expect.MinDelay},
+			{p.SafeTarget, p.Expect.Safe, 0},
+		}
+		for _, check := range checks {
+			got, elapsed, err := send(client, *base+check.endpoint, p.Form())
+			result := "PASS"
+			switch {
+			case err != nil:
+				result = "FAIL: " + err.Error()
+			case got != check.expected:
+				result = "FAIL"
+			case elapsed < check.minDelay:
+				result = fmt.Sprintf("FAIL: expected delay >= %s", check.minDelay)
+			}
+			if result != "PASS" {
+				failures++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, check.endpoint, check.expected, got, elapsed.Round(time.Millisecond), result)
+		}
+	}
+	w.Flush()
+
+	if failures > 0 {
+		fmt.Printf("\n%d check(s) failed\n", failures)
+		os.Exit(1)
+	}
+}
+
+// send posts the form and classifies the response status
+func send(client *http.Client, endpoint string, form map[string]string) (payloads.Outcome, time.Duration, error) {
+	values := url.Values{}
+	for k, v := range form {
+		values.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := client.Post(endpoint, "application/x-www-form-urlencoded", strings.NewReader(values.Encode()))
+	elapsed := time.Since(start)
+	if err != nil {
+		return "", elapsed, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return payloads.OutcomeSuccess, elapsed, nil
+	case http.StatusUnauthorized:
+		return payloads.OutcomeDenied, elapsed, nil
+	case http.StatusBadRequest:
+		return payloads.OutcomeError, elapsed, nil
+	}
+	return "", elapsed, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
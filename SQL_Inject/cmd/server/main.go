@@ -0,0 +1,40 @@
+// Command server runs the SQL injection lab on its own.
+package main
+
+import (
+	"log"
+
+	"shared/config"
+	"shared/guard"
+	sqlinject "sql_inject_demo"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	lab := cfg.Labs[config.SQLi]
+
+	// Connect to SQLite database
+	db, err := gorm.Open(sqlite.Open(lab.DB), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := sqlinject.Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	r, err := guard.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqlinject.RegisterRoutes(&r.RouterGroup, db, lab)
+
+	if err := guard.Run(r, lab.Addr, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,258 @@
+package sqlinject
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"shared/config"
+	"shared/headers"
+	"shared/layout"
+	"sql_inject_demo/payloads"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type User struct {
+	ID       uint   `gorm:"primarykey"`
+	Username string `gorm:"uniqueIndex"`
+	Password string
+	Role     string `gorm:"default:'user'"`
+}
+
+//go:embed templates
+var templates embed.FS
+
+//go:embed assets
+var assets embed.FS
+
+// pages are the html/template pages wrapped in the shared layout
+var pages = layout.MustParse(templates, map[string]string{
+	"index": "templates/index.html",
+})
+
+var (
+	db            *gorm.DB
+	userDirectory = mustParseXML(userDirectoryXML) // XML user directory for the XPath login
+	corpus        = payloads.MustDefault()         // Test cases shown on the index page
+)
+
+// Unsafe login method - vulnerable to SQL injection
+func unsafeLogin(c *gin.Context) {
+	username := c.PostForm("username")
+	password := c.PostForm("password")
+
+	// Simulate some delay for time-based injection detection
+	if strings.Contains(strings.ToLower(username), "sleep") {
+		time.Sleep(2 * time.Second)
+	}
+
+	// Dangerous: directly concatenating SQL statements
+	var result map[string]interface{}
+	// Changed the query format to make basic authentication bypass work
+	sql := fmt.Sprintf("SELECT * FROM users WHERE username='%s' AND password='%s' LIMIT 1", username, password)
+
+	// Log the SQL query for demonstration
+	log.Printf("Executing SQL: %s", sql)
+
+	err := db.Raw(sql).Scan(&result).Error
+
+	if err != nil {
+		// Return error message for error-based injection demonstration
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Login failed with error: %v", err),
+		})
+		return
+	}
+
+	if len(result) > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful",
+			"user":    result,
+		})
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login failed: Invalid credentials",
+		})
+	}
+}
+
+// Safe login method - using parameterized queries
+func safeLogin(c *gin.Context) {
+	username := c.PostForm("username")
+	password := c.PostForm("password")
+
+	var user User
+	result := db.Where("username = ? AND password = ?", username, password).First(&user)
+
+	if result.Error == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful",
+			"user":    user,
+		})
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login failed: Invalid credentials",
+		})
+	}
+}
+
+// Unsafe XPath login - vulnerable to XPath injection
+func unsafeXPathLogin(c *gin.Context) {
+	username := c.PostForm("username")
+	password := c.PostForm("password")
+
+	// Dangerous: concatenating user input into the XPath expression
+	query := fmt.Sprintf("//user[username/text()='%s' and password/text()='%s']", username, password)
+	log.Printf("Executing XPath: %s", query)
+
+	nodes, err := evaluateXPath(userDirectory, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Login failed with error: %v", err),
+		})
+		return
+	}
+
+	if len(nodes) > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful as " + nodes[0].child("username"),
+			"user":    gin.H{"username": nodes[0].child("username"), "role": nodes[0].child("role")},
+		})
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login failed: Invalid credentials",
+		})
+	}
+}
+
+// Safe XPath login - every value is quoted as an XPath literal
+func safeXPathLogin(c *gin.Context) {
+	username := c.PostForm("username")
+	password := c.PostForm("password")
+
+	query := fmt.Sprintf("//user[username/text()=%s and password/text()=%s]", xpathLiteral(username), xpathLiteral(password))
+	log.Printf("Executing XPath: %s", query)
+
+	nodes, err := evaluateXPath(userDirectory, query)
+	if err == nil && len(nodes) > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful as " + nodes[0].child("username"),
+			"user":    gin.H{"username": nodes[0].child("username"), "role": nodes[0].child("role")},
+		})
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login failed: Invalid credentials",
+		})
+	}
+}
+
+// Unsafe LDAP login - vulnerable to LDAP filter injection
+func unsafeLDAPLogin(c *gin.Context) {
+	username := c.PostForm("username")
+	password := c.PostForm("password")
+
+	// Dangerous: concatenating user input into the search filter
+	filter := fmt.Sprintf("(&(uid=%s)(userPassword=%s))", username, password)
+	log.Printf("Executing LDAP search: %s", filter)
+
+	entries, err := searchLDAP(filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Login failed with error: %v", err),
+		})
+		return
+	}
+
+	if len(entries) > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful as " + entries[0].DN,
+			"user":    entries[0].Attributes,
+		})
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login failed: Invalid credentials",
+		})
+	}
+}
+
+// Safe LDAP login - filter metacharacters are escaped
+func safeLDAPLogin(c *gin.Context) {
+	username := c.PostForm("username")
+	password := c.PostForm("password")
+
+	filter := fmt.Sprintf("(&(uid=%s)(userPassword=%s))", ldapEscape(username), ldapEscape(password))
+	log.Printf("Executing LDAP search: %s", filter)
+
+	entries, err := searchLDAP(filter)
+	if err == nil && len(entries) > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful as " + entries[0].DN,
+			"user":    entries[0].Attributes,
+		})
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login failed: Invalid credentials",
+		})
+	}
+}
+
+// Migrate creates the schema and the test users
+func Migrate(db *gorm.DB) error {
+	// Auto migrate schema
+	if err := db.AutoMigrate(&User{}); err != nil {
+		return err
+	}
+
+	// Create test users
+	db.Create(&User{
+		Username: "admin",
+		Password: "123456",
+		Role:     "admin",
+	})
+	db.Create(&User{
+		Username: "user1",
+		Password: "password1",
+		Role:     "user",
+	})
+	db.Create(&User{
+		Username: "user2",
+		Password: "password2",
+		Role:     "user",
+	})
+	return nil
+}
+
+// RegisterRoutes mounts the SQL injection lab on rg, registering only the
+// endpoints enabled in cfg
+func RegisterRoutes(rg *gin.RouterGroup, database *gorm.DB, cfg config.Lab) {
+	db = database
+	routes := cfg.Routes(rg)
+
+	// Serve the shared layout assets, the security headers settings
+	// and this lab's scripts
+	layout.Mount(rg)
+	headers.Mount(rg)
+	assetFS, _ := fs.Sub(assets, "assets")
+	rg.StaticFS("/assets", http.FS(assetFS))
+
+	routes.Unsafe(http.MethodPost, "/unsafe/login", unsafeLogin)
+	routes.Safe(http.MethodPost, "/safe/login", safeLogin)
+	routes.Unsafe(http.MethodPost, "/unsafe/xpath-login", unsafeXPathLogin)
+	routes.Safe(http.MethodPost, "/safe/xpath-login", safeXPathLogin)
+	routes.Unsafe(http.MethodPost, "/unsafe/ldap-login", unsafeLDAPLogin)
+	routes.Safe(http.MethodPost, "/safe/ldap-login", safeLDAPLogin)
+
+	// Provide a simple frontend page
+	rg.GET("/", func(c *gin.Context) {
+		pages.Render(c, http.StatusOK, "index", gin.H{
+			"Corpus": corpus,
+			"Routes": routes,
+		})
+	})
+}
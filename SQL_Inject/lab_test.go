@@ -0,0 +1,90 @@
+package sqlinject
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"shared/config"
+	"sql_inject_demo/payloads"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestServer runs the lab with every endpoint on a fresh database
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	database, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(&r.RouterGroup, database, config.Default().Labs[config.SQLi])
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// outcomes maps response statuses to corpus outcomes, as cmd/attack does
+var outcomes = map[int]payloads.Outcome{
+	http.StatusOK:           payloads.OutcomeSuccess,
+	http.StatusUnauthorized: payloads.OutcomeDenied,
+	http.StatusBadRequest:   payloads.OutcomeError,
+}
+
+// TestCorpus replays every corpus entry against its unsafe and safe
+// endpoints and checks the expected outcome
+func TestCorpus(t *testing.T) {
+	srv := newTestServer(t)
+	corpus := payloads.MustDefault()
+
+	for _, target := range []string{"/unsafe/login", "/unsafe/xpath-login", "/unsafe/ldap-login"} {
+		entries := corpus.ForTarget(target)
+		if len(entries) == 0 {
+			t.Errorf("no payloads for %s", target)
+		}
+		for _, p := range entries {
+			t.Run(p.ID, func(t *testing.T) {
+				values := url.Values{}
+				for k, v := range p.Form() {
+					values.Set(k, v)
+				}
+				checks := []struct {
+					endpoint string
+					expected payloads.Outcome
+					minDelay time.Duration
+				}{
+					{p.Target, p.Expect.Unsafe, p.Expect.MinDelay},
+					{p.SafeTarget, p.Expect.Safe, 0},
+				}
+				for _, check := range checks {
+					start := time.Now()
+					resp, err := http.Post(srv.URL+check.endpoint, "application/x-www-form-urlencoded", strings.NewReader(values.Encode()))
+					if err != nil {
+						t.Fatal(err)
+					}
+					resp.Body.Close()
+					elapsed := time.Since(start)
+
+					if got := outcomes[resp.StatusCode]; got != check.expected {
+						t.Errorf("%s: got %s (HTTP %d), want %s", check.endpoint, got, resp.StatusCode, check.expected)
+					}
+					if elapsed < check.minDelay {
+						t.Errorf("%s: took %s, want at least %s", check.endpoint, elapsed, check.minDelay)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package sqlinject
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LDAPEntry is a directory entry with multi-valued attributes
+type LDAPEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// ldapDirectory is the in-process directory queried by the LDAP login
+var ldapDirectory = []LDAPEntry{
+	{
+		DN: "uid=admin,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"admin"},
+			"userPassword": {"123456"},
+			"cn":           {"Administrator"},
+			"role":         {"admin"},
+		},
+	},
+	{
+		DN: "uid=user1,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"user1"},
+			"userPassword": {"password1"},
+			"cn":           {"User One"},
+			"role":         {"user"},
+		},
+	},
+	{
+		DN: "uid=user2,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"user2"},
+			"userPassword": {"password2"},
+			"cn":           {"User Two"},
+			"role":         {"user"},
+		},
+	},
+}
+
+// ldapFilter is a parsed RFC 4515 search filter
+type ldapFilter interface {
+	Match(entry LDAPEntry) bool
+}
+
+type ldapAnd []ldapFilter
+type ldapOr []ldapFilter
+type ldapNot struct{ inner ldapFilter }
+
+// ldapCompare is an equality, presence or substring assertion.
+// Parts holds the value split on unescaped '*' so that presence is
+// Parts == ["", ""] and a plain equality has a single part.
+type ldapCompare struct {
+	Attr  string
+	Parts []string
+}
+
+func (f ldapAnd) Match(e LDAPEntry) bool {
+	for _, sub := range f {
+		if !sub.Match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f ldapOr) Match(e LDAPEntry) bool {
+	for _, sub := range f {
+		if sub.Match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f ldapNot) Match(e LDAPEntry) bool {
+	return !f.inner.Match(e)
+}
+
+func (f ldapCompare) Match(e LDAPEntry) bool {
+	for name, values := range e.Attributes {
+		if !strings.EqualFold(name, f.Attr) {
+			continue
+		}
+		for _, v := range values {
+			if matchLDAPValue(v, f.Parts) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchLDAPValue matches initial*any*final substring patterns
+func matchLDAPValue(value string, parts []string) bool {
+	if len(parts) == 1 {
+		return value == parts[0]
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	rest := value[len(parts[0]):]
+	for _, mid := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, mid)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(mid):]
+	}
+	return strings.HasSuffix(rest, parts[len(parts)-1])
+}
+
+// ldapEscape escapes a value for use inside a filter (RFC 4515 section 3)
+func ldapEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// parseLDAPFilter parses the first complete filter in s.
+// Like several real directory servers, anything after the first balanced
+// filter is silently ignored - which is what lets an attacker close the
+// intended filter early and comment out the rest.
+func parseLDAPFilter(s string) (ldapFilter, string, error) {
+	p := &ldapParser{src: s}
+	f, err := p.parseFilter()
+	if err != nil {
+		return nil, "", err
+	}
+	return f, s[p.pos:], nil
+}
+
+type ldapParser struct {
+	src string
+	pos int
+}
+
+func (p *ldapParser) parseFilter() (ldapFilter, error) {
+	if p.pos >= len(p.src) || p.src[p.pos] != '(' {
+		return nil, fmt.Errorf("LDAP filter error: expected '(' at offset %d", p.pos)
+	}
+	p.pos++
+	if p.pos >= len(p.src) {
+		return nil, fmt.Errorf("LDAP filter error: unexpected end of filter")
+	}
+
+	var f ldapFilter
+	var err error
+	switch p.src[p.pos] {
+	case '&', '|':
+		op := p.src[p.pos]
+		p.pos++
+		var subs []ldapFilter
+		for p.pos < len(p.src) && p.src[p.pos] == '(' {
+			sub, err := p.parseFilter()
+			if err != nil {
+				return nil, err
+			}
+			subs = append(subs, sub)
+		}
+		if op == '&' {
+			f = ldapAnd(subs)
+		} else {
+			f = ldapOr(subs)
+		}
+	case '!':
+		p.pos++
+		inner, err := p.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		f = ldapNot{inner}
+	default:
+		f, err = p.parseItem()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.pos >= len(p.src) || p.src[p.pos] != ')' {
+		return nil, fmt.Errorf("LDAP filter error: expected ')' at offset %d", p.pos)
+	}
+	p.pos++
+	return f, nil
+}
+
+func (p *ldapParser) parseItem() (ldapFilter, error) {
+	eq := strings.IndexByte(p.src[p.pos:], '=')
+	if eq <= 0 {
+		return nil, fmt.Errorf("LDAP filter error: missing attribute assertion at offset %d", p.pos)
+	}
+	attr := p.src[p.pos : p.pos+eq]
+	if strings.ContainsAny(attr, "()*\\") {
+		return nil, fmt.Errorf("LDAP filter error: invalid attribute description %q", attr)
+	}
+	p.pos += eq + 1
+
+	parts := []string{""}
+	for p.pos < len(p.src) && p.src[p.pos] != ')' {
+		c := p.src[p.pos]
+		switch c {
+		case '(':
+			return nil, fmt.Errorf("LDAP filter error: unescaped '(' in value at offset %d", p.pos)
+		case '*':
+			parts = append(parts, "")
+			p.pos++
+		case '\\':
+			if p.pos+3 > len(p.src) {
+				return nil, fmt.Errorf("LDAP filter error: truncated escape at offset %d", p.pos)
+			}
+			decoded, err := hex.DecodeString(p.src[p.pos+1 : p.pos+3])
+			if err != nil {
+				return nil, fmt.Errorf("LDAP filter error: invalid escape at offset %d", p.pos)
+			}
+			parts[len(parts)-1] += string(decoded)
+			p.pos += 3
+		default:
+			parts[len(parts)-1] += string(c)
+			p.pos++
+		}
+	}
+	return ldapCompare{Attr: attr, Parts: parts}, nil
+}
+
+// searchLDAP returns every entry matching the filter string
+func searchLDAP(filter string) ([]LDAPEntry, error) {
+	f, trailing, err := parseLDAPFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if trailing != "" {
+		// Mimic servers that only log the ignored remainder
+		log.Printf("LDAP: ignoring trailing filter data %q", trailing)
+	}
+
+	var results []LDAPEntry
+	for _, entry := range ldapDirectory {
+		if f.Match(entry) {
+			results = append(results, entry)
+		}
+	}
+	return results, nil
+}
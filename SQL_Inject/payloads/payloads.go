@@ -0,0 +1,152 @@
+// Package payloads loads the versioned injection payload corpus.
+//
+// The corpus is a YAML file so that new payloads can be contributed without
+// touching Go code. The same data renders the demo page and drives the
+// attack CLI in cmd/attack.
+package payloads
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the corpus format version understood by this package
+const CurrentVersion = 1
+
+// Outcome is the expected result class of sending a payload
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success" // login accepted (HTTP 200)
+	OutcomeDenied  Outcome = "denied"  // credentials rejected (HTTP 401)
+	OutcomeError   Outcome = "error"   // query failed (HTTP 400)
+)
+
+// Expectation records how the unsafe and safe endpoints should react
+type Expectation struct {
+	Unsafe   Outcome       `yaml:"unsafe"`
+	Safe     Outcome       `yaml:"safe"`
+	MinDelay time.Duration `yaml:"min_delay,omitempty"` // for time-based payloads
+}
+
+// Payload is a single corpus entry
+type Payload struct {
+	ID          string            `yaml:"id"`
+	Name        string            `yaml:"name"`
+	Category    string            `yaml:"category"`
+	Target      string            `yaml:"target"`      // vulnerable endpoint
+	SafeTarget  string            `yaml:"safe_target"` // hardened counterpart
+	Field       string            `yaml:"field"`       // form field receiving the payload
+	Payload     string            `yaml:"payload"`
+	Fields      map[string]string `yaml:"fields,omitempty"` // other form values
+	Expect      Expectation       `yaml:"expect"`
+	Explanation string            `yaml:"explanation"`
+}
+
+// Form returns the complete set of form values to submit
+func (p Payload) Form() map[string]string {
+	form := make(map[string]string, len(p.Fields)+1)
+	for k, v := range p.Fields {
+		form[k] = v
+	}
+	form[p.Field] = p.Payload
+	return form
+}
+
+// Corpus is the top level document of a payload file
+type Corpus struct {
+	Version  int       `yaml:"version"`
+	Payloads []Payload `yaml:"payloads"`
+}
+
+// ForTarget returns the payloads aimed at the given vulnerable endpoint,
+// in file order
+func (c *Corpus) ForTarget(target string) []Payload {
+	var result []Payload
+	for _, p := range c.Payloads {
+		if p.Target == target {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+//go:embed sqli.yaml
+var defaultCorpus []byte
+
+// Default returns the corpus bundled with the binary
+func Default() (*Corpus, error) {
+	return Parse(defaultCorpus)
+}
+
+// MustDefault is like Default but panics on error
+func MustDefault() *Corpus {
+	c, err := Default()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Load reads and validates a corpus file
+func Load(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload corpus: %v", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates corpus YAML
+func Parse(data []byte) (*Corpus, error) {
+	var corpus Corpus
+	if err := yaml.Unmarshal(data, &corpus); err != nil {
+		return nil, fmt.Errorf("failed to parse payload corpus: %v", err)
+	}
+	if corpus.Version != CurrentVersion {
+		return nil, fmt.Errorf("unsupported payload corpus version %d (expected %d)", corpus.Version, CurrentVersion)
+	}
+
+	seen := make(map[string]bool)
+	for i := range corpus.Payloads {
+		p := &corpus.Payloads[i]
+		if p.Field == "" {
+			p.Field = "username"
+		}
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("payload #%d (%s): %v", i+1, p.ID, err)
+		}
+		if seen[p.ID] {
+			return nil, fmt.Errorf("payload #%d: duplicate id %q", i+1, p.ID)
+		}
+		seen[p.ID] = true
+	}
+	return &corpus, nil
+}
+
+func (p Payload) validate() error {
+	switch {
+	case p.ID == "":
+		return fmt.Errorf("id is required")
+	case p.Name == "":
+		return fmt.Errorf("name is required")
+	case p.Category == "":
+		return fmt.Errorf("category is required")
+	case p.Target == "" || p.SafeTarget == "":
+		return fmt.Errorf("target and safe_target are required")
+	case p.Payload == "":
+		return fmt.Errorf("payload is required")
+	}
+	for _, o := range []Outcome{p.Expect.Unsafe, p.Expect.Safe} {
+		switch o {
+		case OutcomeSuccess, OutcomeDenied, OutcomeError:
+		default:
+			return fmt.Errorf("invalid expected outcome %q", o)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package payloads
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	corpus, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corpus.Payloads) == 0 {
+		t.Fatal("default corpus is empty")
+	}
+	for _, p := range corpus.Payloads {
+		if len(corpus.ForTarget(p.Target)) == 0 {
+			t.Errorf("%s: ForTarget(%q) is empty", p.ID, p.Target)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	const entry = `
+  - id: one
+    name: One
+    category: auth-bypass
+    target: /unsafe/login
+    safe_target: /safe/login
+    payload: "' OR '1'='1"
+    expect:
+      unsafe: success
+      safe: denied
+`
+	tests := []struct {
+		name string
+		yaml string
+		err  string // substring of the expected error, empty for success
+	}{
+		{"valid", "version: 1\npayloads:" + entry, ""},
+		{"bad yaml", "version: [", "failed to parse"},
+		{"wrong version", "version: 2\npayloads:" + entry, "unsupported payload corpus version 2"},
+		{"duplicate id", "version: 1\npayloads:" + entry + entry, `duplicate id "one"`},
+		{"missing id", "version: 1\npayloads:" + strings.Replace(entry, "id: one", "id: ''", 1), "id is required"},
+		{"missing name", "version: 1\npayloads:" + strings.Replace(entry, "name: One", "name: ''", 1), "name is required"},
+		{"missing category", "version: 1\npayloads:" + strings.Replace(entry, "category: auth-bypass", "category: ''", 1), "category is required"},
+		{"missing safe target", "version: 1\npayloads:" + strings.Replace(entry, "safe_target: /safe/login", "safe_target: ''", 1), "target and safe_target are required"},
+		{"missing payload", "version: 1\npayloads:" + strings.Replace(entry, `payload: "' OR '1'='1"`, "payload: ''", 1), "payload is required"},
+		{"bad outcome", "version: 1\npayloads:" + strings.Replace(entry, "safe: denied", "safe: maybe", 1), `invalid expected outcome "maybe"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corpus, err := Parse([]byte(tt.yaml))
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := corpus.Payloads[0].Field; got != "username" {
+					t.Errorf("default field = %q, want username", got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestForm(t *testing.T) {
+	p := Payload{Field: "password", Payload: "*", Fields: map[string]string{"username": "admin", "password": "ignored"}}
+	form := p.Form()
+	if form["username"] != "admin" || form["password"] != "*" {
+		t.Errorf("Form() = %v", form)
+	}
+}
@@ -0,0 +1,582 @@
+package sqlinject
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// userDirectoryXML is the XML user directory queried by the XPath login
+const userDirectoryXML = `<?xml version="1.0" encoding="UTF-8"?>
+<users>
+	<user>
+		<username>admin</username>
+		<password>123456</password>
+		<role>admin</role>
+	</user>
+	<user>
+		<username>user1</username>
+		<password>password1</password>
+		<role>user</role>
+	</user>
+	<user>
+		<username>user2</username>
+		<password>password2</password>
+		<role>user</role>
+	</user>
+</users>`
+
+// xmlNode is a simplified element tree; mixed content is not needed here
+type xmlNode struct {
+	Name     string
+	Text     string
+	Children []*xmlNode
+}
+
+// parseXML builds an element tree and returns a synthetic document root
+func parseXML(src string) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+
+	decoder := xml.NewDecoder(strings.NewReader(src))
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: t.Name.Local}
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			current := stack[len(stack)-1]
+			current.Text += strings.TrimSpace(string(t))
+		}
+	}
+	return root, nil
+}
+
+func mustParseXML(src string) *xmlNode {
+	root, err := parseXML(src)
+	if err != nil {
+		panic(err)
+	}
+	return root
+}
+
+// child returns the text of the first child element with the given name
+func (n *xmlNode) child(name string) string {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c.Text
+		}
+	}
+	return ""
+}
+
+// xpathLiteral quotes s as an XPath 1.0 string literal.
+// XPath has no escape sequences, so a value containing both quote
+// characters has to be assembled with concat().
+func xpathLiteral(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	parts := strings.Split(s, "'")
+	quoted := make([]string, 0, len(parts)*2)
+	for i, part := range parts {
+		if i > 0 {
+			quoted = append(quoted, `"'"`)
+		}
+		if part != "" {
+			quoted = append(quoted, "'"+part+"'")
+		}
+	}
+	return "concat(" + strings.Join(quoted, ", ") + ")"
+}
+
+// evaluateXPath runs a location path such as //user[username/text()='a']
+// against the tree. Only the subset of XPath 1.0 needed by the demo is
+// implemented: child and descendant steps, predicates, comparisons,
+// and/or, parentheses and a handful of string functions.
+func evaluateXPath(root *xmlNode, query string) ([]*xmlNode, error) {
+	tokens, err := tokenizeXPath(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &xpathParser{tokens: tokens}
+
+	nodes := []*xmlNode{root}
+	for !p.done() {
+		descendant := false
+		switch p.peek().value {
+		case "//":
+			descendant = true
+		case "/":
+		default:
+			return nil, fmt.Errorf("XPath syntax error: expected '/' near %q", p.peek().value)
+		}
+		p.next()
+
+		name := p.next()
+		if name.kind != tokName {
+			return nil, fmt.Errorf("XPath syntax error: expected element name near %q", name.value)
+		}
+
+		var matched []*xmlNode
+		for _, n := range nodes {
+			if descendant {
+				matched = append(matched, descendants(n, name.value)...)
+			} else {
+				for _, c := range n.Children {
+					if c.Name == name.value {
+						matched = append(matched, c)
+					}
+				}
+			}
+		}
+
+		for !p.done() && p.peek().value == "[" {
+			p.next()
+			expr, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if tok := p.next(); tok.value != "]" {
+				return nil, fmt.Errorf("XPath syntax error: expected ']' near %q", tok.value)
+			}
+
+			var filtered []*xmlNode
+			for _, n := range matched {
+				v, err := expr(n)
+				if err != nil {
+					return nil, err
+				}
+				if toBool(v) {
+					filtered = append(filtered, n)
+				}
+			}
+			matched = filtered
+		}
+		nodes = matched
+	}
+	return nodes, nil
+}
+
+func descendants(n *xmlNode, name string) []*xmlNode {
+	var result []*xmlNode
+	for _, c := range n.Children {
+		if c.Name == name {
+			result = append(result, c)
+		}
+		result = append(result, descendants(c, name)...)
+	}
+	return result
+}
+
+type xpathTokenKind int
+
+const (
+	tokName xpathTokenKind = iota
+	tokString
+	tokNumber
+	tokSymbol
+	tokEOF
+)
+
+type xpathToken struct {
+	kind  xpathTokenKind
+	value string
+}
+
+func tokenizeXPath(s string) ([]xpathToken, error) {
+	var tokens []xpathToken
+	for i := 0; i < len(s); {
+		ch := rune(s[i])
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(s[i+1:], s[i])
+			if end < 0 {
+				return nil, fmt.Errorf("XPath syntax error: unterminated string literal at offset %d", i)
+			}
+			tokens = append(tokens, xpathToken{tokString, s[i+1 : i+1+end]})
+			i += end + 2
+		case strings.HasPrefix(s[i:], "//"), strings.HasPrefix(s[i:], "!="):
+			tokens = append(tokens, xpathToken{tokSymbol, s[i : i+2]})
+			i += 2
+		case strings.ContainsRune("/[]()=,", ch):
+			tokens = append(tokens, xpathToken{tokSymbol, string(ch)})
+			i++
+		case unicode.IsDigit(ch):
+			start := i
+			for i < len(s) && (unicode.IsDigit(rune(s[i])) || s[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, xpathToken{tokNumber, s[start:i]})
+		case unicode.IsLetter(ch) || ch == '_':
+			start := i
+			for i < len(s) && (unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i])) || strings.IndexByte("_-.", s[i]) >= 0) {
+				i++
+			}
+			tokens = append(tokens, xpathToken{tokName, s[start:i]})
+		default:
+			return nil, fmt.Errorf("XPath syntax error: unexpected character %q at offset %d", ch, i)
+		}
+	}
+	return tokens, nil
+}
+
+// xpathExpr evaluates against a context node and yields a string, float64,
+// bool or []string (node-set of text values)
+type xpathExpr func(ctx *xmlNode) (interface{}, error)
+
+type xpathParser struct {
+	tokens []xpathToken
+	pos    int
+}
+
+func (p *xpathParser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *xpathParser) peek() xpathToken {
+	if p.done() {
+		return xpathToken{kind: tokEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *xpathParser) next() xpathToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *xpathParser) parseOr() (xpathExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokName && p.peek().value == "or" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ctx *xmlNode) (interface{}, error) {
+			lv, err := l(ctx)
+			if err != nil || toBool(lv) {
+				return true, err
+			}
+			rv, err := right(ctx)
+			return toBool(rv), err
+		}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseAnd() (xpathExpr, error) {
+	left, err := p.parseEquality()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokName && p.peek().value == "and" {
+		p.next()
+		right, err := p.parseEquality()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ctx *xmlNode) (interface{}, error) {
+			lv, err := l(ctx)
+			if err != nil || !toBool(lv) {
+				return false, err
+			}
+			rv, err := right(ctx)
+			return toBool(rv), err
+		}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseEquality() (xpathExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek().value
+	if p.peek().kind != tokSymbol || (op != "=" && op != "!=") {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx *xmlNode) (interface{}, error) {
+		lv, err := left(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rv, err := right(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return compareXPath(lv, rv, op == "="), nil
+	}, nil
+}
+
+func (p *xpathParser) parsePrimary() (xpathExpr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokString:
+		return func(*xmlNode) (interface{}, error) { return tok.value, nil }, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("XPath syntax error: invalid number %q", tok.value)
+		}
+		return func(*xmlNode) (interface{}, error) { return n, nil }, nil
+	case tokSymbol:
+		if tok.value != "(" {
+			return nil, fmt.Errorf("XPath syntax error: unexpected %q", tok.value)
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.value != ")" {
+			return nil, fmt.Errorf("XPath syntax error: expected ')' near %q", closing.value)
+		}
+		return expr, nil
+	case tokName:
+		if p.peek().value == "(" && tok.value != "text" {
+			return p.parseFunction(tok.value)
+		}
+		return p.parseRelativePath(tok.value)
+	}
+	return nil, fmt.Errorf("XPath syntax error: unexpected end of expression")
+}
+
+// parseRelativePath handles name/name/text() inside predicates
+func (p *xpathParser) parseRelativePath(first string) (xpathExpr, error) {
+	steps := []string{first}
+	for p.peek().value == "/" {
+		p.next()
+		name := p.next()
+		if name.kind != tokName {
+			return nil, fmt.Errorf("XPath syntax error: expected name after '/'")
+		}
+		steps = append(steps, name.value)
+	}
+	if last := steps[len(steps)-1]; last == "text" {
+		if p.next().value != "(" || p.next().value != ")" {
+			return nil, fmt.Errorf("XPath syntax error: malformed text()")
+		}
+		steps = steps[:len(steps)-1]
+	}
+
+	return func(ctx *xmlNode) (interface{}, error) {
+		nodes := []*xmlNode{ctx}
+		for _, step := range steps {
+			var next []*xmlNode
+			for _, n := range nodes {
+				for _, c := range n.Children {
+					if c.Name == step {
+						next = append(next, c)
+					}
+				}
+			}
+			nodes = next
+		}
+		texts := make([]string, len(nodes))
+		for i, n := range nodes {
+			texts[i] = n.Text
+		}
+		return texts, nil
+	}, nil
+}
+
+func (p *xpathParser) parseFunction(name string) (xpathExpr, error) {
+	p.next() // (
+	var args []xpathExpr
+	for p.peek().value != ")" {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.peek().value == "," {
+			p.next()
+		} else if p.peek().value != ")" {
+			return nil, fmt.Errorf("XPath syntax error: expected ',' or ')' in %s()", name)
+		}
+	}
+	p.next() // )
+
+	evalArgs := func(ctx *xmlNode) ([]interface{}, error) {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			v, err := arg(ctx)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+
+	arity := map[string][2]int{
+		"concat":        {2, -1},
+		"contains":      {2, 2},
+		"starts-with":   {2, 2},
+		"substring":     {2, 3},
+		"string-length": {1, 1},
+		"not":           {1, 1},
+		"true":          {0, 0},
+		"false":         {0, 0},
+	}
+	limits, ok := arity[name]
+	if !ok {
+		return nil, fmt.Errorf("XPath error: unknown function %s()", name)
+	}
+	if len(args) < limits[0] || (limits[1] >= 0 && len(args) > limits[1]) {
+		return nil, fmt.Errorf("XPath error: wrong number of arguments to %s()", name)
+	}
+
+	return func(ctx *xmlNode) (interface{}, error) {
+		v, err := evalArgs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "concat":
+			var b strings.Builder
+			for _, arg := range v {
+				b.WriteString(toString(arg))
+			}
+			return b.String(), nil
+		case "contains":
+			return strings.Contains(toString(v[0]), toString(v[1])), nil
+		case "starts-with":
+			return strings.HasPrefix(toString(v[0]), toString(v[1])), nil
+		case "substring":
+			// XPath positions are 1-based
+			s := []rune(toString(v[0]))
+			start := int(toNumber(v[1])) - 1
+			end := len(s)
+			if len(v) == 3 {
+				end = start + int(toNumber(v[2]))
+			}
+			start = max(start, 0)
+			end = min(end, len(s))
+			if start >= end {
+				return "", nil
+			}
+			return string(s[start:end]), nil
+		case "string-length":
+			return float64(len([]rune(toString(v[0])))), nil
+		case "not":
+			return !toBool(v[0]), nil
+		case "true":
+			return true, nil
+		}
+		return false, nil
+	}, nil
+}
+
+// compareXPath implements "=" (equal) and "!=" with XPath 1.0 semantics.
+// A comparison with a node-set holds if it holds for any node in it, so
+// a != b is not not(a = b): a set with two different values is both equal
+// and unequal to either of them.
+func compareXPath(a, b interface{}, equal bool) bool {
+	// A boolean is compared with the other side as a boolean, node-sets
+	// included
+	_, aBool := a.(bool)
+	_, bBool := b.(bool)
+	if aBool || bBool {
+		return (toBool(a) == toBool(b)) == equal
+	}
+	if set, ok := a.([]string); ok {
+		for _, s := range set {
+			if compareXPath(s, b, equal) {
+				return true
+			}
+		}
+		return false
+	}
+	if set, ok := b.([]string); ok {
+		for _, s := range set {
+			if compareXPath(a, s, equal) {
+				return true
+			}
+		}
+		return false
+	}
+	_, aNum := a.(float64)
+	_, bNum := b.(float64)
+	if aNum || bNum {
+		return (toNumber(a) == toNumber(b)) == equal
+	}
+	return (toString(a) == toString(b)) == equal
+}
+
+func toBool(v interface{}) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case float64:
+		return t != 0
+	case []string:
+		return len(t) > 0
+	}
+	return false
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case []string:
+		if len(t) > 0 {
+			return t[0]
+		}
+	}
+	return ""
+}
+
+func toNumber(v interface{}) float64 {
+	switch t := v.(type) {
+	case float64:
+		return t
+	case bool:
+		if t {
+			return 1
+		}
+		return 0
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(toString(v)), 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
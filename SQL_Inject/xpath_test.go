@@ -0,0 +1,31 @@
+package sqlinject
+
+import "testing"
+
+// TestXPathComparison checks "=" and "!=" against node-sets, which compare
+// existentially: the users element has an admin and two other roles
+func TestXPathComparison(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"//users[user/role/text()='admin']", 1},
+		{"//users[user/role/text()!='admin']", 1},
+		{"//users[not(user/role/text()='admin')]", 0},
+		{"//users[user/role/text()!='nobody']", 1},
+		{"//users[user/nothing/text()!='admin']", 0},
+		{"//user[role/text()!='admin']", 2},
+		{"//user[username/text()!=password/text()]", 3},
+		{"//user['1'!='1']", 0},
+		{"//user[1!=2]", 3},
+	} {
+		nodes, err := evaluateXPath(userDirectory, tt.query)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if len(nodes) != tt.want {
+			t.Errorf("%s: %d nodes, want %d", tt.query, len(nodes), tt.want)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Show the rendered greeting under the message and keep the role current
+function formatGreeting(result) {
+	if (result.role) {
+		document.getElementById('role').textContent = result.role;
+	}
+	let message = result.message;
+	if (result.output) {
+		message += '\n' + result.output;
+	}
+	return message;
+}
+
+bindForm('textForm', 'unsafe/greeting/text', 'textResult', formatGreeting);
+bindForm('htmlForm', 'unsafe/greeting/html', 'htmlResult', formatGreeting);
+bindForm('safeForm', 'safe/greeting', 'safeResult', formatGreeting);
+bindForm('resetForm', 'reset', 'resetResult', formatGreeting);
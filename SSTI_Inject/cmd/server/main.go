@@ -0,0 +1,28 @@
+// Command server runs the template injection lab on its own.
+package main
+
+import (
+	"log"
+
+	"shared/config"
+	"shared/guard"
+	sstiinject "ssti_demo"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	lab := cfg.Labs[config.SSTI]
+
+	r, err := guard.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sstiinject.RegisterRoutes(&r.RouterGroup, lab)
+
+	if err := guard.Run(r, lab.Addr, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
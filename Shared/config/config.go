@@ -0,0 +1,263 @@
+// Package config holds the listen addresses, database paths and
+// vulnerability toggles of the labs.
+//
+// Settings are layered: built-in defaults, then an optional YAML file, then
+// WEBSEC_* environment variables, then command-line flags. A lab's mode
+// decides which of its endpoints are registered, so the same code can be
+// deployed as a hardened-only reference or a vulnerable-only exercise.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mode selects which kind of endpoints a lab exposes
+type Mode string
+
+const (
+	ModeAll    Mode = "all"    // vulnerable and hardened endpoints side by side
+	ModeSafe   Mode = "safe"   // hardened endpoints only
+	ModeUnsafe Mode = "unsafe" // vulnerable endpoints only
+)
+
+// Lab holds the settings of a single lab
+type Lab struct {
+	Addr     string   `yaml:"addr"`     // listen address when run standalone
+	DB       string   `yaml:"db"`       // SQLite file, for labs that use one
+	Mode     Mode     `yaml:"mode"`     // which endpoint kinds are enabled
+	Disabled []string `yaml:"disabled"` // individual endpoint paths to turn off
+	Attacker string   `yaml:"attacker"` // listen address of the lab's attacker server, if it has one
+}
+
+// Vulnerable reports whether the mode allows vulnerable behaviour
+func (l Lab) Vulnerable() bool {
+	return l.Mode != ModeSafe
+}
+
+// Config is the top level configuration shared by every program
+type Config struct {
+	Addr string         `yaml:"addr"` // launcher listen address
+	Labs map[string]Lab `yaml:"labs"`
+
+	// AllowedCIDRs are the networks, besides loopback, allowed to connect
+	AllowedCIDRs []string `yaml:"allowed_cidrs"`
+
+	// Exposed is set only by the -i-understand-this-is-vulnerable flag and
+	// permits binding to a non-loopback interface
+	Exposed bool `yaml:"-"`
+}
+
+// Lab names used as keys in Config.Labs
+const (
+	SQLi  = "sqli"
+	XSS   = "xss"
+	CSRF  = "csrf"
+	NoSQL = "nosql"
+	CMDi  = "cmdi"
+	SSTI  = "ssti"
+)
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Addr: ":8080",
+		Labs: map[string]Lab{
+			SQLi:  {Addr: ":8080", DB: "test.db", Mode: ModeAll},
+			XSS:   {Addr: ":8080", DB: "xss.db", Mode: ModeAll, Attacker: ":9090"},
+			CSRF:  {Addr: ":8080", DB: "csrf.db", Mode: ModeAll, Attacker: ":9091"},
+			NoSQL: {Addr: ":8080", Mode: ModeAll},
+			CMDi:  {Addr: ":8080", Mode: ModeAll},
+			SSTI:  {Addr: ":8080", Mode: ModeAll},
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the file named by -config
+// (or WEBSEC_CONFIG), the environment and the command-line flags, which it
+// registers on flag.CommandLine and parses.
+func Load() (*Config, error) {
+	return LoadArgs(flag.CommandLine, os.Args[1:])
+}
+
+// LoadArgs is like Load with an explicit flag set and argument list
+func LoadArgs(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+
+	path := fs.String("config", os.Getenv("WEBSEC_CONFIG"), "path to a YAML config file")
+	addr := fs.String("addr", "", "launcher listen address")
+	allow := fs.String("allow-cidr", "", "comma-separated networks allowed to connect besides loopback")
+	exposed := fs.Bool("i-understand-this-is-vulnerable", false, "allow binding the vulnerable labs to a non-loopback interface")
+	labFlags := make(map[string]*labFlagSet)
+	for _, name := range cfg.names() {
+		labFlags[name] = newLabFlagSet(fs, name)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		if err := cfg.mergeFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	cfg.mergeEnv()
+
+	if *addr != "" {
+		cfg.Addr = *addr
+	}
+	if *allow != "" {
+		cfg.AllowedCIDRs = splitList(*allow)
+	}
+	cfg.Exposed = *exposed
+	for name, f := range labFlags {
+		lab := cfg.Labs[name]
+		f.apply(&lab)
+		cfg.Labs[name] = lab
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// mergeFile overlays the non-empty settings of a YAML file
+func (c *Config) mergeFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+	var file Config
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse config: %v", err)
+	}
+
+	if file.Addr != "" {
+		c.Addr = file.Addr
+	}
+	if file.AllowedCIDRs != nil {
+		c.AllowedCIDRs = file.AllowedCIDRs
+	}
+	for name, override := range file.Labs {
+		lab, ok := c.Labs[name]
+		if !ok {
+			return fmt.Errorf("config: unknown lab %q", name)
+		}
+		lab.merge(override)
+		c.Labs[name] = lab
+	}
+	return nil
+}
+
+// mergeEnv overlays WEBSEC_ADDR, WEBSEC_ALLOWED_CIDRS and
+// WEBSEC_<LAB>_{ADDR,DB,MODE,DISABLED,ATTACKER}
+func (c *Config) mergeEnv() {
+	if v := os.Getenv("WEBSEC_ADDR"); v != "" {
+		c.Addr = v
+	}
+	if v := os.Getenv("WEBSEC_ALLOWED_CIDRS"); v != "" {
+		c.AllowedCIDRs = splitList(v)
+	}
+	for name, lab := range c.Labs {
+		prefix := "WEBSEC_" + strings.ToUpper(name) + "_"
+		lab.merge(Lab{
+			Addr:     os.Getenv(prefix + "ADDR"),
+			DB:       os.Getenv(prefix + "DB"),
+			Mode:     Mode(os.Getenv(prefix + "MODE")),
+			Disabled: splitList(os.Getenv(prefix + "DISABLED")),
+			Attacker: os.Getenv(prefix + "ATTACKER"),
+		})
+		c.Labs[name] = lab
+	}
+}
+
+func (c *Config) validate() error {
+	for _, cidr := range c.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("config: invalid allowed CIDR %q", cidr)
+		}
+	}
+	for name, lab := range c.Labs {
+		switch lab.Mode {
+		case ModeAll, ModeSafe, ModeUnsafe:
+		default:
+			return fmt.Errorf("config: lab %q has invalid mode %q (want all, safe or unsafe)", name, lab.Mode)
+		}
+	}
+	return nil
+}
+
+// names returns the lab names in a stable order
+func (c *Config) names() []string {
+	names := make([]string, 0, len(c.Labs))
+	for name := range c.Labs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// merge copies the non-empty fields of o into l
+func (l *Lab) merge(o Lab) {
+	if o.Addr != "" {
+		l.Addr = o.Addr
+	}
+	if o.DB != "" {
+		l.DB = o.DB
+	}
+	if o.Mode != "" {
+		l.Mode = o.Mode
+	}
+	if o.Disabled != nil {
+		l.Disabled = o.Disabled
+	}
+	if o.Attacker != "" {
+		l.Attacker = o.Attacker
+	}
+}
+
+// labFlagSet holds the -<lab>.* flags of one lab
+type labFlagSet struct {
+	addr, db, mode, disabled, attacker *string
+}
+
+func newLabFlagSet(fs *flag.FlagSet, name string) *labFlagSet {
+	return &labFlagSet{
+		addr:     fs.String(name+".addr", "", "listen address of the "+name+" lab when run standalone"),
+		db:       fs.String(name+".db", "", "database file of the "+name+" lab"),
+		mode:     fs.String(name+".mode", "", "endpoints of the "+name+" lab to enable: all, safe or unsafe"),
+		disabled: fs.String(name+".disabled", "", "comma-separated endpoint paths of the "+name+" lab to turn off"),
+		attacker: fs.String(name+".attacker", "", "listen address of the "+name+" lab's attacker server"),
+	}
+}
+
+func (f *labFlagSet) apply(l *Lab) {
+	l.merge(Lab{
+		Addr:     *f.addr,
+		DB:       *f.db,
+		Mode:     Mode(*f.mode),
+		Disabled: splitList(*f.disabled),
+		Attacker: *f.attacker,
+	})
+}
+
+// splitList splits a comma-separated list, returning nil for ""
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
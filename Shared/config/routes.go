@@ -0,0 +1,60 @@
+package config
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Routes registers a lab's endpoints according to its mode and disabled
+// list. Endpoints that are turned off are simply not registered, so they
+// answer 404 like any unknown path.
+type Routes struct {
+	rg      *gin.RouterGroup
+	lab     Lab
+	enabled map[string]bool
+}
+
+// Routes returns a registrar for endpoints on rg
+func (l Lab) Routes(rg *gin.RouterGroup) *Routes {
+	return &Routes{rg: rg, lab: l, enabled: make(map[string]bool)}
+}
+
+// Unsafe registers a deliberately vulnerable endpoint
+func (r *Routes) Unsafe(method, path string, handlers ...gin.HandlerFunc) {
+	r.handle(r.Vulnerable(), method, path, handlers)
+}
+
+// Safe registers a hardened endpoint
+func (r *Routes) Safe(method, path string, handlers ...gin.HandlerFunc) {
+	r.handle(r.lab.Mode != ModeUnsafe, method, path, handlers)
+}
+
+// Handle registers an endpoint that serves the vulnerable and the hardened
+// demos alike, such as editing a comment of either kind. Only the disabled
+// list turns it off.
+func (r *Routes) Handle(method, path string, handlers ...gin.HandlerFunc) {
+	r.handle(true, method, path, handlers)
+}
+
+func (r *Routes) handle(allowed bool, method, path string, handlers []gin.HandlerFunc) {
+	for _, disabled := range r.lab.Disabled {
+		if disabled == path {
+			allowed = false
+		}
+	}
+	r.enabled[path] = allowed
+	if allowed {
+		r.rg.Handle(method, path, handlers...)
+	}
+}
+
+// Enabled reports whether the endpoint at path was registered. Pages use
+// it to hide forms that would only get a 404.
+func (r *Routes) Enabled(path string) bool {
+	return r.enabled[path]
+}
+
+// Vulnerable reports whether the lab's mode allows vulnerable behaviour,
+// including client-side demos that have no endpoint of their own
+func (r *Routes) Vulnerable() bool {
+	return r.lab.Vulnerable()
+}
@@ -0,0 +1,126 @@
+// Package guard keeps the intentionally vulnerable labs off the network.
+//
+// Servers bind to loopback unless the operator passes
+// -i-understand-this-is-vulnerable together with a list of allowed CIDRs,
+// and every request from an address outside loopback and those CIDRs is
+// rejected, whatever interface the server ended up listening on.
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"shared/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// New returns a gin engine with the default middleware and the network ACL
+// built from cfg.AllowedCIDRs
+func New(cfg *config.Config) (*gin.Engine, error) {
+	nets, err := parseCIDRs(cfg.AllowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	r := gin.Default()
+	r.Use(ACL(nets))
+	return r, nil
+}
+
+// Run checks addr with ListenAddr and serves r on the result
+func Run(r *gin.Engine, addr string, cfg *config.Config) error {
+	return RunContext(context.Background(), r, addr, cfg)
+}
+
+// RunContext is Run, shutting the server down gracefully when ctx is done
+func RunContext(ctx context.Context, r *gin.Engine, addr string, cfg *config.Config) error {
+	listen, err := ListenAddr(addr, cfg)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: listen, Handler: r}
+	stop := context.AfterFunc(ctx, func() { srv.Shutdown(context.Background()) })
+	defer stop()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ListenAddr resolves the address to bind. An empty host means loopback
+// unless the labs are exposed; a non-loopback host is refused unless the
+// operator acknowledged the risk and restricted clients to allowed CIDRs.
+func ListenAddr(addr string, cfg *config.Config) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid listen address %q: %v", addr, err)
+	}
+
+	if host == "" && !cfg.Exposed {
+		return net.JoinHostPort("127.0.0.1", port), nil
+	}
+	if isLoopbackHost(host) {
+		return addr, nil
+	}
+
+	if !cfg.Exposed {
+		return "", fmt.Errorf("refusing to bind intentionally vulnerable server to %q: pass -i-understand-this-is-vulnerable and -allow-cidr to expose it", addr)
+	}
+	if len(cfg.AllowedCIDRs) == 0 {
+		return "", fmt.Errorf("refusing to bind intentionally vulnerable server to %q without -allow-cidr", addr)
+	}
+	log.Printf("WARNING: intentionally vulnerable server listening on %s, clients restricted to loopback and %v", addr, cfg.AllowedCIDRs)
+	return addr, nil
+}
+
+// ACL rejects requests whose peer address is neither loopback nor inside
+// one of nets. It uses the TCP peer rather than X-Forwarded-For, which any
+// client can forge.
+func ACL(nets []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := net.ParseIP(c.RemoteIP())
+		if ip != nil && allowed(ip, nets) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"message": "Forbidden: client address is not in the allowed networks",
+		})
+	}
+}
+
+func allowed(ip net.IP, nets []*net.IPNet) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed CIDR %q: %v", cidr, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
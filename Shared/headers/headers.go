@@ -0,0 +1,164 @@
+// Package headers lets learners switch HTTP security headers on and off
+// from a settings page, without restarting the servers.
+//
+// The choices are kept in a cookie, so each browser has its own settings
+// and they apply to every lab on the same host. Mount adds a middleware
+// that sends the chosen headers before the lab's handlers run; a header the
+// lab sets itself wins, except Content-Security-Policy, where the browser
+// enforces both policies.
+package headers
+
+import (
+	"embed"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is one security header and the values it can be set to
+type Header struct {
+	Key         string // form field and cookie key
+	Name        string
+	Values      []string
+	Description string
+}
+
+// Headers are the headers the settings page offers
+var Headers = []Header{
+	{
+		Key:         "xcto",
+		Name:        "X-Content-Type-Options",
+		Values:      []string{"nosniff"},
+		Description: "Stops the browser from guessing a type other than the Content-Type, e.g. running an upload served without a type as HTML or a text response as a script.",
+	},
+	{
+		Key:         "xfo",
+		Name:        "X-Frame-Options",
+		Values:      []string{"DENY", "SAMEORIGIN"},
+		Description: "Controls whether other sites may frame the page, the classic defence against clickjacking.",
+	},
+	{
+		Key:         "referrer",
+		Name:        "Referrer-Policy",
+		Values:      []string{"no-referrer", "same-origin", "strict-origin-when-cross-origin", "unsafe-url"},
+		Description: "Limits the URL sent in the Referer header. The DOM XSS referrer source and Referer-checking CSRF defences both depend on it.",
+	},
+	{
+		Key:         "permissions",
+		Name:        "Permissions-Policy",
+		Values:      []string{"camera=(), microphone=(), geolocation=()", "camera=(), microphone=(), geolocation=(), payment=(), usb=(), fullscreen=()"},
+		Description: "Turns off powerful browser features, so an injected script cannot use them either.",
+	},
+	{
+		Key:         "csp",
+		Name:        "Content-Security-Policy",
+		Values:      []string{"default-src 'self'; object-src 'none'; base-uri 'none'", "script-src 'none'", "frame-ancestors 'none'"},
+		Description: "Sent in addition to any policy the lab sets. The browser enforces every policy it receives, so a resource has to pass all of them.",
+	},
+	{
+		Key:         "coop",
+		Name:        "Cross-Origin-Opener-Policy",
+		Values:      []string{"same-origin", "same-origin-allow-popups", "unsafe-none"},
+		Description: "Separates the page from cross-origin windows it opens or is opened by, which breaks window.opener and postMessage tricks across origins.",
+	},
+	{
+		Key:         "coep",
+		Name:        "Cross-Origin-Embedder-Policy",
+		Values:      []string{"require-corp", "credentialless"},
+		Description: "Only lets the page load cross-origin resources that opt in with CORP or CORS, e.g. the attacker collector's hook.js.",
+	},
+	{
+		Key:         "corp",
+		Name:        "Cross-Origin-Resource-Policy",
+		Values:      []string{"same-origin", "same-site", "cross-origin"},
+		Description: "Stops other origins from embedding this lab's responses as images, scripts or frames.",
+	},
+	{
+		Key:         "hsts",
+		Name:        "Strict-Transport-Security",
+		Values:      []string{"max-age=300", "max-age=31536000; includeSubDomains"},
+		Description: "Makes the browser use HTTPS only for max-age seconds. Browsers ignore it over plain HTTP, so it has no effect on these labs unless they run behind TLS.",
+	},
+}
+
+// cookieName holds the selected value index of each header
+const cookieName = "security_headers"
+
+//go:embed templates
+var templates embed.FS
+
+var pages = layout.MustParse(templates, map[string]string{
+	"headers": "templates/headers.html",
+})
+
+// Mount sends the headers chosen in the browser's settings with every
+// response of rg registered after it, and serves the settings page at
+// <group>/security-headers. Call it before registering the lab's routes,
+// including static files.
+func Mount(rg *gin.RouterGroup) {
+	rg.Use(middleware)
+	rg.GET("/security-headers", settingsPage)
+	rg.POST("/security-headers", updateSettings)
+}
+
+// selected returns the chosen value of each header by key
+func selected(c *gin.Context) map[string]string {
+	result := map[string]string{}
+	raw, err := c.Cookie(cookieName)
+	if err != nil {
+		return result
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return result
+	}
+	for _, h := range Headers {
+		i, err := strconv.Atoi(values.Get(h.Key))
+		if err == nil && i >= 0 && i < len(h.Values) {
+			result[h.Key] = h.Values[i]
+		}
+	}
+	return result
+}
+
+func middleware(c *gin.Context) {
+	chosen := selected(c)
+	for _, h := range Headers {
+		value, ok := chosen[h.Key]
+		if !ok {
+			continue
+		}
+		if h.Name == "Content-Security-Policy" {
+			c.Writer.Header().Add(h.Name, value)
+		} else {
+			c.Header(h.Name, value)
+		}
+	}
+	c.Next()
+}
+
+func settingsPage(c *gin.Context) {
+	pages.Render(c, http.StatusOK, "headers", gin.H{
+		"Headers":  Headers,
+		"Selected": selected(c),
+	})
+}
+
+// updateSettings stores the submitted choices. A header whose field is
+// empty or unknown is not sent.
+func updateSettings(c *gin.Context) {
+	values := url.Values{}
+	for _, h := range Headers {
+		i, err := strconv.Atoi(c.PostForm(h.Key))
+		if err == nil && i >= 0 && i < len(h.Values) {
+			values.Set(h.Key, strconv.Itoa(i))
+		}
+	}
+	// The cookie covers the whole host, so the settings apply to every lab
+	c.SetCookie(cookieName, values.Encode(), 0, "/", "", false, true)
+	c.Redirect(http.StatusFound, layout.BasePath(c)+"security-headers")
+}
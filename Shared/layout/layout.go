@@ -0,0 +1,118 @@
+// Package layout provides the page layout, stylesheet and form helpers
+// shared by every lab.
+//
+// A lab page is an html/template file that defines "title" and "content"
+// (and optionally "head" and "scripts"); Parse combines it with the shared
+// "layout" template. Values are escaped according to their context, so any
+// intentionally vulnerable output has to be passed in as template.HTML (or
+// template.JS, template.URL, ...) by the handler, where it is easy to spot.
+package layout
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+//go:embed static
+var staticFS embed.FS
+
+// Funcs are available in every page template
+var Funcs = template.FuncMap{
+	// inc turns a zero-based range index into a list number
+	"inc": func(i int) int { return i + 1 },
+}
+
+// Pages holds one parsed template set per page
+type Pages map[string]*template.Template
+
+// Parse builds a template set for each named page.
+// pages maps a page name to its file inside fsys.
+func Parse(fsys fs.FS, pages map[string]string) (Pages, error) {
+	result := make(Pages, len(pages))
+	for name, file := range pages {
+		t, err := template.New(name).Funcs(Funcs).ParseFS(templateFS, "templates/layout.html")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.ParseFS(fsys, file); err != nil {
+			return nil, err
+		}
+		result[name] = t
+	}
+	return result, nil
+}
+
+// MustParse is like Parse but panics on error
+func MustParse(fsys fs.FS, pages map[string]string) Pages {
+	p, err := Parse(fsys, pages)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Render writes the named page wrapped in the shared layout.
+// data["Base"] is set to the lab's base path, which the layout emits as
+// <base href>, so pages can use relative URLs wherever the lab is mounted.
+// data["Nonce"] is the CSP nonce set with SetNonce, if any.
+func (p Pages) Render(c *gin.Context, status int, page string, data gin.H) {
+	if data == nil {
+		data = gin.H{}
+	}
+	data["Base"] = BasePath(c)
+	data["Nonce"] = Nonce(c)
+	c.Render(status, render.HTML{
+		Template: p[page],
+		Name:     "layout",
+		Data:     data,
+	})
+}
+
+// Context keys holding the lab's mount point and the request's CSP nonce
+const (
+	basePathKey = "layout.basePath"
+	nonceKey    = "layout.nonce"
+)
+
+// Mount serves the shared stylesheet and scripts under <group>/common and
+// records the group's base path for Render and BasePath. Call it before
+// registering the lab's own routes.
+func Mount(rg *gin.RouterGroup) {
+	base := strings.TrimSuffix(rg.BasePath(), "/") + "/"
+	rg.Use(func(c *gin.Context) {
+		c.Set(basePathKey, base)
+	})
+
+	static, _ := fs.Sub(staticFS, "static")
+	rg.StaticFS("/common", http.FS(static))
+}
+
+// BasePath returns the mount point of the current lab with a trailing
+// slash, e.g. "/" when running standalone or "/xss/" under the launcher
+func BasePath(c *gin.Context) string {
+	if base := c.GetString(basePathKey); base != "" {
+		return base
+	}
+	return "/"
+}
+
+// SetNonce records the Content-Security-Policy nonce of the current
+// request. The layout adds it to its own scripts and pages should add it to
+// theirs with {{with .Nonce}} nonce="{{.}}"{{end}}.
+func SetNonce(c *gin.Context, nonce string) {
+	c.Set(nonceKey, nonce)
+}
+
+// Nonce returns the nonce recorded by SetNonce, or "" without a policy
+func Nonce(c *gin.Context) string {
+	return c.GetString(nonceKey)
+}
@@ -0,0 +1,32 @@
+// Shared helpers for the demo forms
+
+function showResult(elementId, success, message) {
+	const element = document.getElementById(elementId);
+	element.style.display = 'block';
+	element.className = 'result ' + (success ? 'success' : 'error');
+	element.textContent = message;
+}
+
+// bindForm submits a form with fetch and shows the JSON message.
+// format(result) can be passed to customise the displayed text.
+// Forms that are not on the page (disabled endpoints) are skipped.
+function bindForm(formId, url, resultId, format) {
+	const form = document.getElementById(formId);
+	if (!form) {
+		return;
+	}
+	form.onsubmit = async (e) => {
+		e.preventDefault();
+		const formData = new FormData(e.target);
+		try {
+			const response = await fetch(url, {
+				method: 'POST',
+				body: formData
+			});
+			const result = await response.json();
+			showResult(resultId, response.ok, format ? format(result) : result.message);
+		} catch (error) {
+			showResult(resultId, false, 'Request failed: ' + error.message);
+		}
+	};
+}
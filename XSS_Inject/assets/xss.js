@@ -0,0 +1,67 @@
+// With Trusted Types enforced (see the toggle on the page) the DOM sinks
+// below only accept values from a policy. Without enforcement they get the
+// raw string, which is the vulnerability this page demonstrates.
+var trustedTypesEnforced = document.currentScript.dataset.trustedTypes === 'enforced' && !!window.trustedTypes;
+
+function escapeHTML(s) {
+	return String(s)
+		.replace(/&/g, '&amp;')
+		.replace(/</g, '&lt;')
+		.replace(/>/g, '&gt;')
+		.replace(/"/g, '&quot;')
+		.replace(/'/g, '&#39;');
+}
+
+var htmlPolicy = null;
+if (trustedTypesEnforced) {
+	// The policy the page's own code uses. It escapes; an application that
+	// needs markup would call a sanitizer such as DOMPurify here.
+	htmlPolicy = trustedTypes.createPolicy('xss-demo', {
+		createHTML: escapeHTML
+	});
+
+	// The default policy is called for every string that reaches a sink
+	// without going through a policy, such as code an attacker injected
+	trustedTypes.createPolicy('default', {
+		createHTML: function (s, type, sink) {
+			console.warn('Trusted Types default policy escaped a string written to ' + sink);
+			return escapeHTML(s);
+		},
+		createScript: function (s, type, sink) {
+			console.warn('Trusted Types default policy blocked a script string passed to ' + sink);
+			return null;
+		},
+		createScriptURL: function (s, type, sink) {
+			var url = new URL(s, location.href);
+			if (url.origin !== location.origin) {
+				console.warn('Trusted Types default policy blocked ' + url.href + ' for ' + sink);
+				return null;
+			}
+			return s;
+		}
+	});
+}
+
+// trustedHTML prepares markup for an HTML sink
+function trustedHTML(s) {
+	// Unsafe unless enforced: without a policy the string is used as is
+	return htmlPolicy ? htmlPolicy.createHTML(s) : s;
+}
+
+function showGreeting() {
+	var name = document.getElementById('userInput').value;
+	document.getElementById('output').innerHTML = trustedHTML('Hello, ' + name + '!');
+}
+
+// Bound here rather than with onclick so the button works under CSP
+document.getElementById('greetButton').addEventListener('click', showGreeting);
+
+// Get URL fragment and display it (DOM-based XSS)
+if(window.location.hash) {
+	var hash = window.location.hash.slice(1);
+	document.getElementById('output').innerHTML = trustedHTML(decodeURIComponent(hash));
+}
+
+// The demo app keeps an API token in localStorage, out of reach of
+// HttpOnly and just as easy for injected scripts to steal
+localStorage.setItem('apiToken', 'demo-token-for-alice');
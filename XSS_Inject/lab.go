@@ -0,0 +1,226 @@
+package xssinject
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+
+	"shared/config"
+	"shared/headers"
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Comment represents a stored message. Content is always stored exactly
+// as submitted; Mode decides how it is encoded when it is displayed.
+type Comment struct {
+	ID       uint   `gorm:"primarykey"`
+	Author   string `gorm:"not null;default:anonymous"`
+	Content  string `gorm:"not null"`
+	Mode     string `gorm:"not null;default:unsafe;index"`
+	Reviewed bool   `gorm:"not null;default:false"` // approved by a moderator
+}
+
+// Comment modes, one list per mode on the page
+const (
+	commentUnsafe       = "unsafe"        // rendered as raw HTML
+	commentSafe         = "safe"          // plain text, encoded by the template on output
+	commentRich         = "rich"          // sanitized on output
+	commentMarkdown     = "markdown"      // Markdown with raw HTML passed through
+	commentMarkdownSafe = "markdown-safe" // Markdown without raw HTML, then sanitized
+)
+
+//go:embed templates
+var templates embed.FS
+
+//go:embed assets
+var assets embed.FS
+
+//go:embed static
+var staticFiles embed.FS
+
+// pages are the html/template pages wrapped in the shared layout
+var pages = layout.MustParse(templates, map[string]string{
+	"index":      "templates/index.html",
+	"search":     "templates/search.html",
+	"reflect":    "templates/reflect.html",
+	"reports":    "templates/reports.html",
+	"attacker":   "templates/attacker.html",
+	"sanitizer":  "templates/sanitizer.html",
+	"moderation": "templates/moderation.html",
+	"edit":       "templates/edit.html",
+})
+
+var db *gorm.DB
+
+// Migrate creates the comment, CSP report and upload tables
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Comment{}, &CSPReport{}, &Upload{})
+}
+
+// RegisterRoutes mounts the XSS lab on rg, registering only the endpoints
+// enabled in cfg
+func RegisterRoutes(rg *gin.RouterGroup, database *gorm.DB, cfg config.Lab) {
+	db = database
+	routes := cfg.Routes(rg)
+
+	// Serve the shared layout assets, the security headers settings
+	// and this lab's scripts
+	layout.Mount(rg)
+	headers.Mount(rg)
+	assetFS, _ := fs.Sub(assets, "assets")
+	rg.StaticFS("/assets", http.FS(assetFS))
+
+	// Serve static files: the embedded client-side demos, then anything
+	// in ./static on disk, which includes unsafe uploads. The DOM XSS
+	// catalog is vulnerable by design, so it is left out in safe mode.
+	dirs := staticDirs{gin.Dir("./static", false)}
+	if routes.Vulnerable() {
+		staticFS, _ := fs.Sub(staticFiles, "static")
+		dirs = append(staticDirs{http.FS(staticFS)}, dirs...)
+	}
+	rg.StaticFS("/static", dirs)
+	// Safe uploads, with their own headers instead of the page policy
+	rg.GET("/files/:name", serveUpload)
+
+	// Apply the selected Content-Security-Policy to every page
+	rg.Use(cspMiddleware)
+	rg.POST("/csp", selectPolicy)
+	rg.POST("/trusted-types", toggleTrustedTypes)
+	rg.POST("/csp-report", collectReport)
+	rg.GET("/csp-reports", listReports)
+	rg.POST("/csp-reports/clear", clearReports)
+
+	// Victim session for the cookie theft demo
+	rg.POST("/cookie-settings", updateCookieFlags)
+	rg.GET("/account", account)
+
+	// Main page with all XSS examples
+	rg.GET("/", func(c *gin.Context) {
+		data := policyPanel(c)
+		data["Session"] = ensureSession(c)
+		data["CookieFlags"] = currentFlags()
+		data["SameSiteModes"] = []string{"Lax", "Strict", "None"}
+		if cfg.Attacker != "" && cfg.Vulnerable() {
+			data["Attacker"] = attackerOrigin(c, cfg.Attacker)
+		}
+		data["UnsafeComments"] = commentList(c, commentUnsafe)
+		data["SafeComments"] = commentList(c, commentSafe)
+		data["RichComments"] = commentList(c, commentRich)
+		data["MarkdownComments"] = commentList(c, commentMarkdown)
+		data["SafeMarkdownComments"] = commentList(c, commentMarkdownSafe)
+		data["Pending"] = pendingComments()
+		data["UnsafeUploads"] = uploadList(uploadUnsafe)
+		data["SafeUploads"] = uploadList(uploadSafe)
+		data["Contexts"] = outputContexts
+		data["Routes"] = routes
+		pages.Render(c, http.StatusOK, "index", data)
+	})
+
+	// Reflected XSS endpoint
+	routes.Unsafe(http.MethodGet, "/search", func(c *gin.Context) {
+		query := c.Query("q")
+		// Unsafe: marking user input as trusted HTML disables escaping
+		pages.Render(c, http.StatusOK, "search", gin.H{
+			"Query": template.HTML(query),
+		})
+	})
+
+	// Reflected XSS in other output contexts, each with a contextually
+	// escaped counterpart
+	for _, ctx := range outputContexts {
+		routes.Unsafe(http.MethodGet, "/reflect/"+ctx.Name, reflectHandler(ctx, false))
+		routes.Safe(http.MethodGet, "/safe/reflect/"+ctx.Name, reflectHandler(ctx, true))
+	}
+
+	// Stored XSS endpoint
+	routes.Unsafe(http.MethodPost, "/comment", func(c *gin.Context) {
+		// Stored as submitted; the vulnerability is in commentHTML
+		createComment(c, commentUnsafe, c.PostForm("content"))
+		c.Redirect(http.StatusFound, layout.BasePath(c))
+	})
+
+	// Safe comment endpoint
+	routes.Safe(http.MethodPost, "/safe-comment", func(c *gin.Context) {
+		// Stored as submitted; escaping happens on output, where the
+		// context is known, so the text is never escaped twice
+		createComment(c, commentSafe, c.PostForm("content"))
+		c.Redirect(http.StatusFound, layout.BasePath(c))
+	})
+
+	// Rich-text comment endpoint
+	routes.Safe(http.MethodPost, "/rich-comment", func(c *gin.Context) {
+		// Stored as submitted and sanitized on output, so sanitizer
+		// fixes apply to existing comments too
+		createComment(c, commentRich, c.PostForm("content"))
+		c.Redirect(http.StatusFound, layout.BasePath(c))
+	})
+
+	// Markdown comment endpoints
+	routes.Unsafe(http.MethodPost, "/markdown-comment", func(c *gin.Context) {
+		createComment(c, commentMarkdown, c.PostForm("content"))
+		c.Redirect(http.StatusFound, layout.BasePath(c))
+	})
+	routes.Safe(http.MethodPost, "/safe-markdown-comment", func(c *gin.Context) {
+		createComment(c, commentMarkdownSafe, c.PostForm("content"))
+		c.Redirect(http.StatusFound, layout.BasePath(c))
+	})
+
+	// Editing and deleting comments, as HTML forms and as a JSON API.
+	// Edits are only accepted in modes whose form endpoint is enabled.
+	editable := editableMode(routes)
+	routes.Handle(http.MethodGet, "/comments/:id/edit", editable, editCommentPage)
+	routes.Handle(http.MethodPost, "/comments/:id", editable, updateCommentForm)
+	routes.Handle(http.MethodPost, "/comments/:id/delete", deleteCommentForm)
+	routes.Handle(http.MethodGet, "/api/comments", listCommentsAPI)
+	routes.Handle(http.MethodPost, "/api/comments", createCommentAPI(routes))
+	routes.Handle(http.MethodGet, "/api/comments/:id", getCommentAPI)
+	routes.Handle(http.MethodPut, "/api/comments/:id", editable, updateCommentAPI)
+	routes.Handle(http.MethodDelete, "/api/comments/:id", deleteCommentAPI)
+
+	// Legacy comment APIs: JSON with a wrong Content-Type, and JSONP
+	routes.Unsafe(http.MethodGet, "/api/legacy/comments", unsafeLegacyJSON)
+	routes.Safe(http.MethodGet, "/safe/api/legacy/comments", safeLegacyJSON)
+	routes.Unsafe(http.MethodGet, "/jsonp/comments", unsafeJSONP)
+	routes.Safe(http.MethodGet, "/safe/jsonp/comments", safeJSONP)
+
+	// Avatar and attachment uploads
+	routes.Unsafe(http.MethodPost, "/upload", unsafeUpload)
+	routes.Safe(http.MethodPost, "/safe-upload", safeUpload)
+
+	// Moderation queue, which the admin bot started by RunAdminBot reviews
+	// as a privileged victim of stored XSS
+	announceModeratorPassword()
+	adminQueue = moderationQueue(routes)
+	routes.Handle(http.MethodPost, "/admin/login", moderatorLogin)
+	routes.Handle(http.MethodGet, "/admin/moderation", adminQueue)
+	routes.Handle(http.MethodPost, "/admin/moderation/:id/:action", moderate)
+
+	// Sanitizer bypass challenges
+	rg.GET("/sanitizer", func(c *gin.Context) {
+		pages.Render(c, http.StatusOK, "sanitizer", gin.H{
+			"Challenges": runChallenges(),
+		})
+	})
+}
+
+// staticDirs serves a path from the first file system that has it
+type staticDirs []http.FileSystem
+
+func (dirs staticDirs) Open(name string) (http.File, error) {
+	// Directory requests end in a slash, which embedded file systems reject
+	name = path.Clean("/" + name)
+	err := fs.ErrNotExist
+	for _, dir := range dirs {
+		f, openErr := dir.Open(name)
+		if openErr == nil {
+			return f, nil
+		}
+		err = openErr
+	}
+	return nil, err
+}
@@ -0,0 +1,83 @@
+package xssinject
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// callbackPattern allows JavaScript identifiers and dotted paths such as
+// jQuery's jQuery3600_123 or app.handlers.comments
+var callbackPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+const maxCallback = 64
+
+// legacyComments is the page of comments the legacy endpoints return
+func legacyComments(c *gin.Context) []renderedComment {
+	page, _ := strconv.Atoi(c.Query("page"))
+	comments := loadComments(c, "", page).Comments
+	if comments == nil {
+		comments = []renderedComment{}
+	}
+	return comments
+}
+
+// rawJSON encodes v the way many hand-written APIs do, without escaping
+// <, > and & inside strings
+func rawJSON(v any) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// Unsafe JSON - comments served as text/html, so opening the URL renders
+// any markup stored in them
+func unsafeLegacyJSON(c *gin.Context) {
+	body := rawJSON(gin.H{"message": "OK", "comments": legacyComments(c)})
+	// Unsafe: the wrong Content-Type makes the browser parse JSON as HTML
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+}
+
+// Safe JSON - the right Content-Type, no sniffing, and HTML characters
+// escaped inside strings
+func safeLegacyJSON(c *gin.Context) {
+	c.Header("X-Content-Type-Options", "nosniff")
+	// c.JSON escapes <, > and & as \u003c, \u003e and \u0026
+	c.JSON(http.StatusOK, gin.H{"message": "OK", "comments": legacyComments(c)})
+}
+
+// Unsafe JSONP - the callback parameter is written into the script as-is
+func unsafeJSONP(c *gin.Context) {
+	callback := c.DefaultQuery("callback", "callback")
+	body := rawJSON(gin.H{"message": "OK", "comments": legacyComments(c)})
+	// Unsafe: callback=alert(document.domain)// turns the response into
+	// any script the attacker wants, served from this origin
+	script := callback + "(" + string(body) + ");"
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(script))
+}
+
+// Safe JSONP - only identifier callbacks, a comment prefix and no sniffing
+func safeJSONP(c *gin.Context) {
+	callback := c.DefaultQuery("callback", "callback")
+	if len(callback) > maxCallback || !callbackPattern.MatchString(callback) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid callback name"})
+		return
+	}
+	body, err := json.Marshal(gin.H{"message": "OK", "comments": legacyComments(c)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.Header("X-Content-Type-Options", "nosniff")
+	// The leading comment keeps the response from starting with
+	// attacker-chosen bytes, which content sniffers such as Flash used to
+	// treat as a different file type
+	script := "/**/" + callback + "(" + string(body) + ");"
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(script))
+}
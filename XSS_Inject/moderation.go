@@ -0,0 +1,165 @@
+package xssinject
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"shared/config"
+	"shared/layout"
+	"xss_demo/verify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// botInterval is how often the admin bot reviews the moderation queue
+const botInterval = 10 * time.Second
+
+// adminQueue is the moderation queue handler of the mounted lab, which the
+// admin bot fetches
+var adminQueue gin.HandlerFunc
+
+// queueEntry is a comment as the moderator sees it
+type queueEntry struct {
+	ID      uint
+	Mode    string
+	Text    string        // plain text comments, encoded by the template
+	Content template.HTML // comments rendered as markup
+}
+
+// moderatorLogin switches the browser to the moderator's session if the
+// moderator password is right
+func moderatorLogin(c *gin.Context) {
+	if subtle.ConstantTimeCompare([]byte(c.PostForm("password")), []byte(moderatorPassword)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Wrong moderator password"})
+		return
+	}
+	issueSession(c, moderator)
+	c.Redirect(http.StatusFound, layout.BasePath(c)+"admin/moderation")
+}
+
+// moderationQueue lists the comments nobody has reviewed yet. New comments
+// wait here before they are published, so every payload is shown to the
+// moderator first, whose session is worth far more. Comments of modes
+// whose endpoint is disabled, such as unsafe comments left over from
+// before the lab was switched to safe mode, are shown as plain text.
+func moderationQueue(routes *config.Routes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user, _ := sessionUser(c); user != moderator {
+			c.JSON(http.StatusForbidden, gin.H{"message": "Only moderators can view the queue"})
+			return
+		}
+
+		var comments []Comment
+		db.Where("reviewed = ?", false).Order("id").Find(&comments)
+		entries := make([]queueEntry, len(comments))
+		for i, comment := range comments {
+			entries[i] = queueEntry{ID: comment.ID, Mode: comment.Mode}
+			if markup, ok := commentHTML(comment); ok && routes.Enabled(commentEndpoints[comment.Mode]) {
+				entries[i].Content = markup
+			} else {
+				entries[i].Text = comment.Content
+			}
+		}
+		pages.Render(c, http.StatusOK, "moderation", gin.H{"Entries": entries, "Routes": routes})
+	}
+}
+
+// approveComments approves every comment in the moderation queue
+func approveComments() {
+	db.Model(&Comment{}).Where("reviewed = ?", false).Update("reviewed", true)
+}
+
+// pendingComments is the length of the moderation queue
+func pendingComments() int64 {
+	var count int64
+	db.Model(&Comment{}).Where("reviewed = ?", false).Count(&count)
+	return count
+}
+
+// moderate approves or rejects a queued comment
+func moderate(c *gin.Context) {
+	if user, _ := sessionUser(c); user != moderator {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Only moderators can review comments"})
+		return
+	}
+
+	id := c.Param("id")
+	switch c.Param("action") {
+	case "approve":
+		db.Model(&Comment{}).Where("id = ?", id).Update("reviewed", true)
+	case "reject":
+		db.Delete(&Comment{}, "id = ?", id)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown action"})
+		return
+	}
+	c.Redirect(http.StatusFound, layout.BasePath(c)+"admin/moderation")
+}
+
+// RunAdminBot plays a moderator who opens the queue of the lab mounted by
+// RegisterRoutes every botInterval with an admin session, until ctx is
+// done. It cannot run scripts, so it scans the page with the verifier and
+// reports what each new comment would have made its browser run or
+// request to the attacker collector. Servers run it alongside the
+// collector.
+func RunAdminBot(ctx context.Context) {
+	if adminQueue == nil {
+		return
+	}
+	session := newSession(moderator)
+	defer sessions.Delete(session)
+	reported := map[string]bool{}
+
+	ticker := time.NewTicker(botInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		seen := map[string]bool{}
+		for _, f := range verify.Scan(fetchQueue(adminQueue, session)) {
+			if !strings.HasPrefix(f.Section, "comment-") {
+				continue
+			}
+			key := f.Section + "\x00" + f.Context + "\x00" + f.Tag + f.Attr + "\x00" + f.Code
+			seen[key] = true
+			if reported[key] {
+				continue
+			}
+
+			target := "<" + f.Tag
+			if f.Attr != "" {
+				target += " " + f.Attr
+			}
+			collect(stolenEntry{
+				Time:       time.Now(),
+				Kind:       "admin-bot " + f.Context,
+				Data:       fmt.Sprintf("%s> %s", target, f.Code),
+				Page:       "admin/moderation#" + f.Section,
+				RemoteAddr: "admin bot",
+			})
+		}
+		// Forget comments that have left the queue
+		reported = seen
+	}
+}
+
+// fetchQueue renders the moderation queue for session through its
+// handler, as the moderator's browser would receive it
+func fetchQueue(queue gin.HandlerFunc, session string) string {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/admin/moderation", nil)
+	c.Request.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+	queue(c)
+	return w.Body.String()
+}
@@ -0,0 +1,195 @@
+package xssinject
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"xss_demo/verify"
+)
+
+// canaryPlaceholder is replaced with a probe's canary in its payload
+const canaryPlaceholder = "CANARY"
+
+// gifPolyglot is a valid GIF header whose screen descriptor is the start
+// of a script tag
+const gifPolyglot = "GIF89a<script>CANARY()</script>"
+
+// Probe plants a payload in one endpoint and checks whether it would run
+type Probe struct {
+	Name    string
+	Method  string
+	Path    string // endpoint the payload is sent to
+	Field   string // query or form field holding the payload
+	Payload string // CANARY is replaced with a unique function name
+	File    string // upload Payload as a file with this name instead
+	Type    string // Content-Type declared for File
+	View    string // page the output is read from, if not the response
+	Admin   bool   // read View with the moderator's session, before approval
+	Unsafe  bool   // expected verdict: unsafe endpoints must be exploitable
+}
+
+// ProbeResult is the verdict of one probe
+type ProbeResult struct {
+	Probe
+	Canary   string
+	Status   int // status of the page that was analyzed
+	Findings []verify.Finding
+	Skipped  bool // the endpoint is not registered
+}
+
+// Exploitable reports whether the payload landed in an executable context
+func (r ProbeResult) Exploitable() bool {
+	return len(r.Findings) > 0
+}
+
+// Pass reports whether the verdict is the expected one
+func (r ProbeResult) Pass() bool {
+	return r.Skipped || r.Exploitable() == r.Unsafe
+}
+
+// Probes covers every server-rendered sink of the lab, including uploaded
+// files. The DOM demos run
+// in the browser and cannot be checked from rendered markup.
+func Probes() []Probe {
+	probes := []Probe{
+		{Name: "Reflected search", Method: http.MethodGet, Path: "/search", Field: "q", Payload: "<script>CANARY()</script>", Unsafe: true},
+		{Name: "Stored comment", Method: http.MethodPost, Path: "/comment", Field: "content", Payload: "<img src=x onerror=CANARY()>", View: "/", Unsafe: true},
+		{Name: "Safe comment", Method: http.MethodPost, Path: "/safe-comment", Field: "content", Payload: "<img src=x onerror=CANARY()>", View: "/"},
+		{Name: "Rich comment handler", Method: http.MethodPost, Path: "/rich-comment", Field: "content", Payload: "<b onmouseover=CANARY()>hi</b>", View: "/"},
+		{Name: "Rich comment link", Method: http.MethodPost, Path: "/rich-comment", Field: "content", Payload: `<a href="&#106;avascript:CANARY()">hi</a>`, View: "/"},
+		{Name: "Markdown comment link", Method: http.MethodPost, Path: "/markdown-comment", Field: "content", Payload: "[x](javascript:CANARY())", View: "/", Unsafe: true},
+		{Name: "Markdown comment HTML", Method: http.MethodPost, Path: "/markdown-comment", Field: "content", Payload: "<img src=x onerror=CANARY()>", View: "/", Unsafe: true},
+		{Name: "Safe Markdown comment link", Method: http.MethodPost, Path: "/safe-markdown-comment", Field: "content", Payload: "[x](javascript:CANARY())", View: "/"},
+		{Name: "Safe Markdown comment HTML", Method: http.MethodPost, Path: "/safe-markdown-comment", Field: "content", Payload: "<img src=x onerror=CANARY()>", View: "/"},
+		{Name: "Legacy JSON API", Method: http.MethodPost, Path: "/comment", Field: "content", Payload: "<img src=x onerror=CANARY()>", View: "/api/legacy/comments", Unsafe: true},
+		{Name: "Legacy JSON API (safe)", Method: http.MethodPost, Path: "/safe-comment", Field: "content", Payload: "<img src=x onerror=CANARY()>", View: "/safe/api/legacy/comments"},
+		{Name: "Moderation queue", Method: http.MethodPost, Path: "/comment", Field: "content", Payload: "<svg onload=CANARY()>", View: "/admin/moderation", Admin: true, Unsafe: true},
+		{Name: "Moderation queue (safe comment)", Method: http.MethodPost, Path: "/safe-comment", Field: "content", Payload: "<svg onload=CANARY()>", View: "/admin/moderation", Admin: true},
+		// A GIF header followed by markup: it sniffs and decodes as a GIF,
+		// so only the headers it is served with decide whether it runs
+		{Name: "Upload HTML as image", Method: http.MethodPost, Path: "/upload", Field: "file", File: "CANARY.html", Type: "image/gif", Payload: gifPolyglot, Unsafe: true},
+		{Name: "Upload SVG", Method: http.MethodPost, Path: "/upload", Field: "file", File: "CANARY.svg", Type: "image/svg+xml", Payload: `<svg xmlns="http://www.w3.org/2000/svg" onload="CANARY()"/>`, Unsafe: true},
+		{Name: "Safe upload GIF polyglot", Method: http.MethodPost, Path: "/safe-upload", Field: "file", File: "CANARY.gif", Type: "image/gif", Payload: gifPolyglot},
+		{Name: "Safe upload SVG", Method: http.MethodPost, Path: "/safe-upload", Field: "file", File: "CANARY.svg", Type: "image/svg+xml", Payload: `<svg xmlns="http://www.w3.org/2000/svg" onload="CANARY()"/>`},
+	}
+	for _, ctx := range outputContexts {
+		// The context payloads call alert('XSS'); call the canary instead
+		payload := strings.ReplaceAll(ctx.Payload, "alert('XSS')", canaryPlaceholder+"()")
+		probes = append(probes,
+			Probe{Name: ctx.Title, Method: http.MethodGet, Path: "/reflect/" + ctx.Name, Field: "q", Payload: payload, Unsafe: true},
+			Probe{Name: ctx.Title + " (safe)", Method: http.MethodGet, Path: "/safe/reflect/" + ctx.Name, Field: "q", Payload: payload},
+		)
+	}
+	return probes
+}
+
+// RunProbes sends each probe to the lab served by h and analyzes the
+// rendered output. Every probe gets its own canary, so stored payloads of
+// one probe cannot be mistaken for another's.
+func RunProbes(h http.Handler, probes []Probe) []ProbeResult {
+	results := make([]ProbeResult, len(probes))
+	for i, p := range probes {
+		canary := fmt.Sprintf("xssProbe%d", i)
+		payload := strings.ReplaceAll(p.Payload, canaryPlaceholder, canary)
+		results[i] = ProbeResult{Probe: p, Canary: canary}
+
+		values := url.Values{p.Field: {payload}}
+		var req *http.Request
+		switch {
+		case p.File != "":
+			req = uploadRequest(p, strings.ReplaceAll(p.File, canaryPlaceholder, canary), payload)
+		case p.Method == http.MethodGet:
+			req = httptest.NewRequest(p.Method, p.Path+"?"+values.Encode(), nil)
+		default:
+			req = httptest.NewRequest(p.Method, p.Path, strings.NewReader(values.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code == http.StatusNotFound {
+			results[i].Skipped = true
+			continue
+		}
+
+		viewPath := p.View
+		if p.File != "" {
+			// A rejected upload leaves only the response to analyze
+			viewPath = uploadURL(strings.ReplaceAll(p.File, canaryPlaceholder, canary))
+		}
+		if viewPath != "" {
+			view := httptest.NewRequest(http.MethodGet, viewPath, nil)
+			if p.Admin {
+				view.AddCookie(&http.Cookie{Name: sessionCookie, Value: newSession(moderator)})
+			} else {
+				// Stored payloads reach public pages once they are approved
+				approveComments()
+			}
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, view)
+		}
+		results[i].Status = w.Code
+		if rendersAsPage(w.Header()) {
+			results[i].Findings = verify.Analyze(w.Body.String(), canary)
+		}
+	}
+	return results
+}
+
+// uploadRequest sends payload as the multipart file p.Field named name
+func uploadRequest(p Probe, name, payload string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, p.Field, name))
+	header.Set("Content-Type", p.Type)
+	part, _ := mw.CreatePart(header)
+	part.Write([]byte(payload))
+	mw.Close()
+
+	req := httptest.NewRequest(p.Method, p.Path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// uploadURL is where the latest upload of the file the client called
+// original is served, or "" if it was rejected
+func uploadURL(original string) string {
+	var upload Upload
+	if err := db.Where("original = ?", original).Order("id desc").First(&upload).Error; err != nil {
+		return ""
+	}
+	if upload.Mode == uploadSafe {
+		return "/files/" + upload.Name
+	}
+	return "/" + unsafeUploadDir + "/" + upload.Name
+}
+
+// rendersAsPage reports whether a browser opening a response with header h
+// renders it as a page of this origin, where scripts in it can run
+func rendersAsPage(h http.Header) bool {
+	if strings.HasPrefix(h.Get("Content-Disposition"), "attachment") {
+		return false
+	}
+	for _, policy := range h.Values("Content-Security-Policy") {
+		if strings.Contains(policy, "sandbox") && !strings.Contains(policy, "allow-scripts") {
+			return false
+		}
+	}
+	if h.Get("X-Content-Type-Options") == "nosniff" {
+		// The declared type is final: only markup types render as pages
+		mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+		switch mediaType {
+		case "text/html", "application/xhtml+xml", "image/svg+xml", "text/xml", "application/xml":
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package xssinject
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shared/config"
+	"xss_demo/verify"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestLab serves the lab in the given mode on a fresh database, from a
+// temporary directory that receives the uploads
+func newTestLab(t *testing.T, mode config.Mode) *gin.Engine {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	database, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "xss.db")), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+
+	lab := config.Default().Labs[config.XSS]
+	lab.Mode = mode
+	// No collector, so no admin bot either
+	lab.Attacker = ""
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(&r.RouterGroup, database, lab)
+	return r
+}
+
+// TestProbes checks that every unsafe endpoint is exploitable and every
+// safe one is not, and that safe mode leaves no unsafe endpoint behind
+func TestProbes(t *testing.T) {
+	for _, mode := range []config.Mode{config.ModeAll, config.ModeSafe} {
+		t.Run(string(mode), func(t *testing.T) {
+			r := newTestLab(t, mode)
+			for _, res := range RunProbes(r, Probes()) {
+				if !res.Pass() {
+					t.Errorf("%s (%s %s): exploitable = %v, want %v", res.Name, res.Method, res.Path, res.Exploitable(), res.Unsafe)
+				}
+				if res.Skipped && mode == config.ModeAll {
+					t.Errorf("%s (%s %s): endpoint is not registered", res.Name, res.Method, res.Path)
+				}
+				if !res.Skipped && res.Unsafe && mode == config.ModeSafe {
+					t.Errorf("%s (%s %s): unsafe endpoint is registered in safe mode", res.Name, res.Method, res.Path)
+				}
+			}
+		})
+	}
+}
+
+// TestModerationQueueSafeMode checks that a stored payload left over from
+// an unsafe run is not rendered once the lab runs in safe mode
+func TestModerationQueueSafeMode(t *testing.T) {
+	r := newTestLab(t, config.ModeSafe)
+	db.Create(&Comment{Author: "anonymous", Mode: commentUnsafe, Content: "<img src=x onerror=leftover()>"})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/moderation", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: newSession(moderator)})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "&lt;img src=x onerror=leftover()&gt;") {
+		t.Error("comment is not in the queue as text")
+	}
+	if findings := verify.Analyze(w.Body.String(), "leftover"); len(findings) > 0 {
+		t.Errorf("payload is executable in the queue: %+v", findings)
+	}
+}
+
+// TestModeratorLogin checks that the moderator session needs the password
+func TestModeratorLogin(t *testing.T) {
+	r := newTestLab(t, config.ModeAll)
+	for _, tt := range []struct {
+		password string
+		want     int
+	}{
+		{"", http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized},
+		{moderatorPassword, http.StatusFound},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(url.Values{"password": {tt.password}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("password %q: status = %d, want %d", tt.password, w.Code, tt.want)
+		}
+	}
+}
+
+// TestSafeUploadPolyglot checks that the GIF polyglot gets past the safe
+// upload's checks, so its probe tests the headers the file is served with
+func TestSafeUploadPolyglot(t *testing.T) {
+	r := newTestLab(t, config.ModeSafe)
+	for _, res := range RunProbes(r, Probes()) {
+		if res.Name != "Safe upload GIF polyglot" {
+			continue
+		}
+		if res.Status != http.StatusOK {
+			t.Fatalf("status = %d, want the upload to be accepted and served", res.Status)
+		}
+		if res.Exploitable() {
+			t.Errorf("upload is exploitable: %+v", res.Findings)
+		}
+		return
+	}
+	t.Fatal("no safe upload GIF polyglot probe")
+}
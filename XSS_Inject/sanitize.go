@@ -0,0 +1,213 @@
+package xssinject
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags maps each permitted element to its permitted attributes
+var allowedTags = map[atom.Atom][]string{
+	atom.B:          nil,
+	atom.I:          nil,
+	atom.Em:         nil,
+	atom.Strong:     nil,
+	atom.U:          nil,
+	atom.S:          nil,
+	atom.Del:        nil,
+	atom.Code:       nil,
+	atom.Pre:        nil,
+	atom.Blockquote: nil,
+	atom.P:          nil,
+	atom.Br:         nil,
+	atom.Ul:         nil,
+	atom.Ol:         nil,
+	atom.Li:         nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.A:          {"href", "title"},
+}
+
+// droppedTags are removed together with their content. Everything else
+// that is not allowed is unwrapped, keeping its text.
+var droppedTags = map[atom.Atom]bool{
+	atom.Script:    true,
+	atom.Style:     true,
+	atom.Noscript:  true,
+	atom.Template:  true,
+	atom.Iframe:    true,
+	atom.Object:    true,
+	atom.Embed:     true,
+	atom.Svg:       true,
+	atom.Math:      true,
+	atom.Title:     true,
+	atom.Textarea:  true,
+	atom.Xmp:       true,
+	atom.Noembed:   true,
+	atom.Noframes:  true,
+	atom.Plaintext: true,
+	atom.Select:    true,
+}
+
+// allowedSchemes are the URL schemes permitted in href
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// sanitizeHTML keeps only allow-listed markup from untrusted rich text.
+//
+// The input is parsed with the HTML5 parsing algorithm, the same one the
+// browser uses, so the sanitizer sees the tree the browser would build
+// rather than guessing with string matching. The output is serialized
+// from scratch: text is escaped, attributes are always quoted and only
+// allowed elements are written, so re-parsing it cannot produce anything
+// new (the root cause of mutation XSS).
+func sanitizeHTML(input string) string {
+	context := &nethtml.Node{Type: nethtml.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := nethtml.ParseFragment(strings.NewReader(input), context)
+	if err != nil {
+		// The HTML5 parser accepts any input; be safe regardless
+		return html.EscapeString(input)
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		writeSanitized(&b, n)
+	}
+	return b.String()
+}
+
+func writeSanitized(b *strings.Builder, n *nethtml.Node) {
+	switch n.Type {
+	case nethtml.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case nethtml.ElementNode:
+	default:
+		// Comments, doctypes and anything else are dropped
+		return
+	}
+
+	// Foreign content (SVG, MathML) is never allowed, whatever its name
+	if n.Namespace != "" || droppedTags[n.DataAtom] {
+		return
+	}
+
+	attrs, allowed := allowedTags[n.DataAtom]
+	if allowed {
+		b.WriteString("<" + n.Data)
+		for _, a := range n.Attr {
+			if a.Namespace != "" || !contains(attrs, a.Key) {
+				continue
+			}
+			if a.Key == "href" && !safeURL(a.Val) {
+				continue
+			}
+			b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+		}
+		if n.DataAtom == atom.A {
+			b.WriteString(` rel="nofollow noopener noreferrer"`)
+		}
+		b.WriteString(">")
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeSanitized(b, c)
+	}
+
+	if allowed && n.DataAtom != atom.Br {
+		b.WriteString("</" + n.Data + ">")
+	}
+}
+
+// safeURL reports whether a link target is relative or uses an allowed
+// scheme. The parser has already decoded character references, and
+// browsers ignore ASCII tab and newline inside URLs and leading control
+// characters and spaces, so those are removed before looking at the scheme.
+func safeURL(raw string) bool {
+	cleaned := strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, raw)
+	cleaned = strings.TrimLeft(cleaned, "\x00\x01\x02\x03\x04\x05\x06\x07\x08\x0b\x0c\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f ")
+
+	u, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		// Relative URL; a colon before any slash would still be a scheme
+		// to the browser, so refuse those
+		first := strings.IndexAny(cleaned, "/?#")
+		colon := strings.Index(cleaned, ":")
+		return colon < 0 || (first >= 0 && first < colon)
+	}
+	return allowedSchemes[strings.ToLower(u.Scheme)]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bypassChallenge is a payload the sanitizer must neutralize
+type bypassChallenge struct {
+	Name        string
+	Payload     string
+	Explanation string
+}
+
+var bypassChallenges = []bypassChallenge{
+	{"Script Tag", `<script>alert('XSS')</script>`, "Script elements are removed together with their content"},
+	{"Event Handler", `<b onmouseover="alert('XSS')">hover me</b>`, "Allowed tags keep only allow-listed attributes"},
+	{"Disallowed Tag", `<img src=x onerror="alert('XSS')">`, "img is not on the allow-list, so it is dropped with its attributes"},
+	{"javascript: URL", `<a href="javascript:alert('XSS')">click</a>`, "Only http, https, mailto and relative links survive"},
+	{"Mixed Case and Spaces", `<a href="  JaVaScRiPt:alert('XSS')">click</a>`, "Schemes are case-insensitive and leading spaces are ignored by browsers"},
+	{"Entity-Encoded Scheme", `<a href="&#106;avascript&colon;alert('XSS')">click</a>`, "Character references are decoded by the parser before the scheme check"},
+	{"Tab Inside Scheme", `<a href="java&#9;script:alert('XSS')">click</a>`, "Browsers strip tabs and newlines from URLs, so the check does too"},
+	{"data: URL", `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgnWFNTJyk8L3NjcmlwdD4=">click</a>`, "data: is not an allowed scheme"},
+	{"Style Attribute", `<b style="background:url(javascript:alert('XSS'))">styled</b>`, "style is not an allowed attribute"},
+	{"Nested Tag Split", `<scr<script>ipt>alert('XSS')</script>`, "A regex that strips <script> once would join the halves; the parser sees the real tree"},
+	{"Unclosed Tag", `<img src=x onerror=alert('XSS')//`, "The parser treats the unterminated tag the way a browser would"},
+	{"Comment Confusion", `<!--><img src=x onerror=alert('XSS')>-->`, "<!--> is an empty comment in HTML5, so the img is real markup and gets dropped"},
+	{"noscript mXSS", `<noscript><p title="</noscript><img src=x onerror=alert('XSS')>">`, "Parsed differently with scripting on and off; noscript is removed entirely"},
+	{"SVG Namespace", `<svg><p><style><img src=x onerror=alert('XSS')></style></p></svg>`, "Foreign content changes how style is parsed; SVG and MathML are never allowed"},
+	{"MathML Namespace Confusion", `<math><mtext><table><mglyph><style><img src=x onerror=alert('XSS')>`, "A classic DOMPurify bypass; foreign elements are dropped with their content"},
+	{"Attribute Breakout", `<a title='x"><img src=x onerror=alert(1)>'>hi</a>`, "Attribute values are re-escaped and always double-quoted on output"},
+}
+
+// challengeResult is a challenge with the sanitizer's output
+type challengeResult struct {
+	bypassChallenge
+	Output string
+	Stable bool // sanitizing the output again changes nothing
+}
+
+func runChallenges() []challengeResult {
+	results := make([]challengeResult, len(bypassChallenges))
+	for i, ch := range bypassChallenges {
+		out := sanitizeHTML(ch.Payload)
+		results[i] = challengeResult{
+			bypassChallenge: ch,
+			Output:          out,
+			Stable:          sanitizeHTML(out) == out,
+		}
+	}
+	return results
+}
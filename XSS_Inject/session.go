@@ -0,0 +1,176 @@
+package xssinject
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionCookie is the victim's session, the target of the cookie theft demo
+const sessionCookie = "xss_session"
+
+// victim is the user every new browser is logged in as
+const victim = "alice"
+
+// moderator is the privileged user who reviews new comments
+const moderator = "admin"
+
+// moderatorPasswordEnv sets the moderator password. Without it a random
+// one is generated and printed once to stderr, never to the request log.
+const moderatorPasswordEnv = "WEBSEC_XSS_MODERATOR_PASSWORD"
+
+// moderatorPassword lets whoever runs the lab sign in as the moderator
+var moderatorPassword = func() string {
+	if password := os.Getenv(moderatorPasswordEnv); password != "" {
+		return password
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	generatedPassword = true
+	return hex.EncodeToString(b)
+}()
+
+var (
+	generatedPassword bool
+	announceOnce      sync.Once
+)
+
+// announceModeratorPassword prints a generated moderator password the
+// first time a lab is mounted
+func announceModeratorPassword() {
+	announceOnce.Do(func() {
+		if generatedPassword {
+			fmt.Fprintf(os.Stderr, "XSS lab moderator password: %s (set %s to choose one)\n", moderatorPassword, moderatorPasswordEnv)
+		}
+	})
+}
+
+// cookieFlags are the attributes the session cookie is issued with
+type cookieFlags struct {
+	HttpOnly bool
+	Secure   bool
+	SameSite string // Lax, Strict or None
+}
+
+var (
+	sessions sync.Map // session id -> username
+
+	flagsMu sync.Mutex
+	// Unsafe: by default scripts can read the session cookie
+	flags = cookieFlags{SameSite: "Lax"}
+)
+
+var sameSiteModes = map[string]http.SameSite{
+	"Lax":    http.SameSiteLaxMode,
+	"Strict": http.SameSiteStrictMode,
+	"None":   http.SameSiteNoneMode,
+}
+
+func currentFlags() cookieFlags {
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+	return flags
+}
+
+// ensureSession logs the browser in as the victim if it has no valid
+// session and returns the session id
+func ensureSession(c *gin.Context) string {
+	if id, err := c.Cookie(sessionCookie); err == nil {
+		if _, ok := sessions.Load(id); ok {
+			return id
+		}
+	}
+	return issueSession(c, victim)
+}
+
+// newSession logs user in and returns the session id
+func newSession(user string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	sessions.Store(id, user)
+	return id
+}
+
+// sessionUser returns who the request's session cookie belongs to
+func sessionUser(c *gin.Context) (string, bool) {
+	id, _ := c.Cookie(sessionCookie)
+	user, ok := sessions.Load(id)
+	if !ok {
+		return "", false
+	}
+	return user.(string), true
+}
+
+// issueSession creates a new session for user and sets the cookie with
+// the current flags
+func issueSession(c *gin.Context, user string) string {
+	id := newSession(user)
+	f := currentFlags()
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     layout.BasePath(c),
+		HttpOnly: f.HttpOnly,
+		Secure:   f.Secure,
+		SameSite: sameSiteModes[f.SameSite],
+	})
+	return id
+}
+
+// updateCookieFlags changes the session cookie attributes and issues a new
+// session so the browser picks them up
+func updateCookieFlags(c *gin.Context) {
+	sameSite := c.PostForm("samesite")
+	if _, ok := sameSiteModes[sameSite]; !ok {
+		sameSite = "Lax"
+	}
+	flagsMu.Lock()
+	flags = cookieFlags{
+		HttpOnly: c.PostForm("httponly") != "",
+		Secure:   c.PostForm("secure") != "",
+		SameSite: sameSite,
+	}
+	flagsMu.Unlock()
+
+	issueSession(c, victim)
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+// account reports who the session cookie belongs to. Replaying a stolen
+// cookie here shows the session has been hijacked.
+func account(c *gin.Context) {
+	user, ok := sessionUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Not logged in"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged in as " + user,
+		"user":    user,
+	})
+}
+
+// attackerOrigin is the URL of the attacker collector as seen from the
+// browser that requested the page
+func attackerOrigin(c *gin.Context, addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = c.Request.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
@@ -0,0 +1,341 @@
+// DOM XSS catalog: pick a source (where attacker data enters the page) and
+// a sink (where the page turns it into markup or code). The selected demo
+// runs when the page loads, the way real DOM XSS does, so nothing here
+// ever reaches the server.
+
+var params = new URLSearchParams(location.search);
+var output = document.getElementById('output');
+var statusLine = document.getElementById('status');
+var storageKey = 'domDemoPayload';
+
+function escapeHTML(s) {
+	return String(s)
+		.replace(/&/g, '&amp;')
+		.replace(/</g, '&lt;')
+		.replace(/>/g, '&gt;')
+		.replace(/"/g, '&quot;')
+		.replace(/'/g, '&#39;');
+}
+
+// The one place this page is allowed to create Trusted Types. HTML is
+// escaped; a real application would run it through a sanitizer such as
+// DOMPurify instead. Scripts are never built from strings.
+var policy = window.trustedTypes && trustedTypes.createPolicy('dom-demo', {
+	createHTML: escapeHTML,
+	createScript: function () {
+		throw new TypeError('The dom-demo policy does not create scripts from strings');
+	}
+});
+
+// greet is the application function the eval and setTimeout sinks call.
+// It is global because string timeouts run in the global scope.
+function greet(name) {
+	output.textContent = 'Hello, ' + name + '!';
+}
+
+// frameDocument returns the document of a fresh same-origin frame inside
+// the output area
+function frameDocument() {
+	var frame = document.createElement('iframe');
+	output.appendChild(frame);
+	return frame.contentDocument;
+}
+
+// jq mimics jQuery's $(el).html() and .text(). Unlike innerHTML, html()
+// also runs every <script> element in the markup.
+function jq(el) {
+	return {
+		html: function (markup) {
+			el.innerHTML = markup;
+			el.querySelectorAll('script').forEach(function (old) {
+				var script = document.createElement('script');
+				script.text = old.text;
+				old.replaceWith(script);
+			});
+		},
+		text: function (s) {
+			el.textContent = s;
+		}
+	};
+}
+
+function link(href) {
+	var a = document.createElement('a');
+	a.setAttribute('href', href);
+	a.textContent = 'Visit your website';
+	output.appendChild(a);
+}
+
+// Sources: deliver puts the payload where an attacker would, read is the
+// vulnerable application code that reads it back
+var sources = {
+	hash: {
+		title: 'location.hash',
+		note: 'The fragment is never sent to the server, so server-side filters and logs never see the payload. A hashchange also re-runs the demo.',
+		deliver: function (url, payload) {
+			url.hash = encodeURIComponent(payload);
+		},
+		read: function () {
+			return decodeURIComponent(location.hash.slice(1));
+		}
+	},
+	search: {
+		title: 'location.search',
+		note: 'The query string is sent to the server but only used by client-side code, so the server never escapes it.',
+		deliver: function (url, payload) {
+			url.searchParams.set('q', payload);
+		},
+		read: function () {
+			return params.get('q') || '';
+		}
+	},
+	referrer: {
+		title: 'document.referrer',
+		note: 'The URL of the previous page. An attacker controls it by linking here from a URL that contains the payload; this demo bounces through such a URL first.',
+		deliver: function (url, payload) {
+			url.searchParams.set('q', payload);
+			url.searchParams.set('bounce', '1');
+		},
+		read: function () {
+			try {
+				return new URL(document.referrer).searchParams.get('q') || '';
+			} catch (e) {
+				return '';
+			}
+		}
+	},
+	postMessage: {
+		title: 'postMessage',
+		note: 'Any window holding a reference to this one (an opener or a parent frame) can post messages. This demo posts the payload to itself on load; the safe variant also checks event.origin.',
+		deliver: function (url, payload) {
+			url.searchParams.set('q', payload);
+		}
+	},
+	windowName: {
+		title: 'window.name',
+		note: 'window.name survives navigation to another origin, so an attacker page can set it and then navigate here.',
+		deliver: function (url, payload) {
+			window.name = payload;
+		},
+		read: function () {
+			return window.name;
+		}
+	},
+	localStorage: {
+		title: 'localStorage',
+		note: 'Stored client-side data is attacker-controlled if any earlier injection, or another page on the origin, wrote it. The payload persists across visits.',
+		deliver: function (url, payload) {
+			localStorage.setItem(storageKey, payload);
+		},
+		read: function () {
+			return localStorage.getItem(storageKey) || '';
+		}
+	}
+};
+
+// Sinks: each has the vulnerable code and its fixes. On the Trusted Types
+// page, enforcement turns every unsafe string assignment into a TypeError.
+var sinks = {
+	innerHTML: {
+		title: 'element.innerHTML',
+		payload: '<img src=x onerror=alert(document.domain)>',
+		unsafe: function (v) {
+			output.innerHTML = 'Hello, ' + v + '!';
+		},
+		safe: function (v) {
+			output.textContent = 'Hello, ' + v + '!';
+		},
+		trusted: function (v) {
+			output.innerHTML = policy.createHTML('Hello, ' + v + '!');
+		}
+	},
+	documentWrite: {
+		title: 'document.write()',
+		payload: '<img src=x onerror=alert(document.domain)>',
+		unsafe: function (v) {
+			var doc = frameDocument();
+			doc.open();
+			doc.write('<p>Welcome back, ' + v + '</p>');
+			doc.close();
+		},
+		safe: function (v) {
+			frameDocument().body.textContent = 'Welcome back, ' + v;
+		},
+		trusted: function (v) {
+			var doc = frameDocument();
+			doc.open();
+			doc.write(policy.createHTML('<p>Welcome back, ' + v + '</p>'));
+			doc.close();
+		}
+	},
+	eval: {
+		title: 'eval()',
+		payload: "');alert(document.domain);//",
+		unsafe: function (v) {
+			eval("greet('" + v + "')");
+		},
+		safe: function (v) {
+			greet(v);
+		},
+		trusted: function (v) {
+			eval(policy.createScript("greet('" + v + "')"));
+		}
+	},
+	setTimeout: {
+		title: 'setTimeout(string)',
+		payload: "');alert(document.domain);//",
+		unsafe: function (v) {
+			setTimeout("greet('" + v + "')", 0);
+		},
+		safe: function (v) {
+			setTimeout(function () { greet(v); }, 0);
+		},
+		trusted: function (v) {
+			setTimeout(policy.createScript("greet('" + v + "')"), 0);
+		}
+	},
+	jqueryHtml: {
+		title: 'jQuery-style .html()',
+		payload: '<script>alert(document.domain)</script>',
+		unsafe: function (v) {
+			jq(output).html('Hello, ' + v + '!');
+		},
+		safe: function (v) {
+			jq(output).text('Hello, ' + v + '!');
+		},
+		trusted: function (v) {
+			jq(output).html(policy.createHTML('Hello, ' + v + '!'));
+		}
+	},
+	setAttributeHref: {
+		title: "element.setAttribute('href')",
+		payload: 'javascript:alert(document.domain)',
+		unsafe: function (v) {
+			link(v);
+		},
+		safe: function (v) {
+			var url = new URL(v, location.href);
+			if (url.protocol !== 'http:' && url.protocol !== 'https:') {
+				throw new TypeError('Refusing ' + url.protocol + ' URL');
+			}
+			link(url.href);
+		},
+		// href is not a Trusted Types sink, but enforcement blocks
+		// navigating to javascript: URLs when the link is clicked
+		trusted: function (v) {
+			link(v);
+		}
+	},
+	srcdoc: {
+		title: 'iframe.srcdoc',
+		payload: '<img src=x onerror=alert(parent.document.domain)>',
+		unsafe: function (v) {
+			var frame = document.createElement('iframe');
+			frame.srcdoc = v;
+			output.appendChild(frame);
+		},
+		safe: function (v) {
+			var frame = document.createElement('iframe');
+			// An empty sandbox gives the frame a unique origin with no scripts
+			frame.setAttribute('sandbox', '');
+			frame.srcdoc = escapeHTML(v);
+			output.appendChild(frame);
+		},
+		trusted: function (v) {
+			var frame = document.createElement('iframe');
+			frame.srcdoc = policy.createHTML(v);
+			output.appendChild(frame);
+		}
+	}
+};
+
+var form = document.getElementById('domForm');
+var sourceSelect = form.elements.source;
+var sinkSelect = form.elements.sink;
+var variantSelect = form.elements.variant;
+var payloadInput = form.elements.payload;
+
+function describe() {
+	var source = sources[sourceSelect.value];
+	var sink = sinks[sinkSelect.value];
+	document.getElementById('sourceNote').textContent = source.title + ': ' + source.note;
+	document.getElementById('sinkTitle').textContent = sink.title;
+}
+
+function run(value) {
+	var sink = sinks[sinkSelect.value];
+	var variant = variantSelect.value;
+	output.textContent = '';
+	statusLine.textContent = '';
+	if (variant === 'trusted' && !policy) {
+		statusLine.textContent = 'This browser does not support Trusted Types';
+		return;
+	}
+	try {
+		sink[variant](value);
+	} catch (e) {
+		statusLine.textContent = 'Blocked: ' + e.message;
+	}
+}
+
+// onMessage is the vulnerable listener of the postMessage source
+function onMessage(event) {
+	if (variantSelect.value !== 'unsafe' && event.origin !== location.origin) {
+		statusLine.textContent = 'Ignored a message from ' + event.origin;
+		return;
+	}
+	run(String(event.data));
+}
+
+sinkSelect.addEventListener('change', function () {
+	payloadInput.value = sinks[sinkSelect.value].payload;
+	describe();
+});
+sourceSelect.addEventListener('change', describe);
+
+form.addEventListener('submit', function (event) {
+	event.preventDefault();
+	var url = new URL(location.pathname, location.href);
+	url.searchParams.set('source', sourceSelect.value);
+	url.searchParams.set('sink', sinkSelect.value);
+	url.searchParams.set('variant', variantSelect.value);
+	sources[sourceSelect.value].deliver(url, payloadInput.value);
+	location.assign(url.href);
+});
+
+// Restore the selected demo and run it
+if (sources[params.get('source')]) {
+	sourceSelect.value = params.get('source');
+}
+if (sinks[params.get('sink')]) {
+	sinkSelect.value = params.get('sink');
+}
+Array.prototype.forEach.call(variantSelect.options, function (option) {
+	if (option.value === params.get('variant')) {
+		variantSelect.value = option.value;
+	}
+});
+payloadInput.value = sinks[sinkSelect.value].payload;
+describe();
+
+if (params.get('bounce')) {
+	// Navigate away from the URL carrying the payload so that it becomes
+	// document.referrer of the next page
+	var next = new URL(location.href);
+	next.searchParams.delete('q');
+	next.searchParams.delete('bounce');
+	location.replace(next.href);
+} else if (params.has('source')) {
+	var source = sources[sourceSelect.value];
+	if (sourceSelect.value === 'postMessage') {
+		window.addEventListener('message', onMessage);
+		window.postMessage(params.get('q') || '', '*');
+	} else {
+		run(source.read());
+		if (sourceSelect.value === 'hash') {
+			window.addEventListener('hashchange', function () {
+				run(source.read());
+			});
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package xssinject
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	// Decoders for validating safe uploads
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"shared/layout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Upload is a file attached by a user
+type Upload struct {
+	ID          uint   `gorm:"primarykey"`
+	Name        string `gorm:"not null"` // file name on disk
+	Original    string `gorm:"not null"` // name the client sent
+	ContentType string `gorm:"not null"` // unsafe: as declared, safe: as detected
+	Size        int64  `gorm:"not null"`
+	Mode        string `gorm:"not null;index"` // uploadUnsafe or uploadSafe
+	Uploader    string `gorm:"not null;default:anonymous"`
+}
+
+// Upload modes
+const (
+	uploadUnsafe = "unsafe"
+	uploadSafe   = "safe"
+)
+
+const (
+	maxUploadSize = 1 << 20
+
+	// unsafeUploadDir is inside ./static, which is served as-is. Files
+	// there get a Content-Type from their extension, or from sniffing.
+	unsafeUploadDir = "static/uploads"
+	// safeUploadDir is outside the web root; files are only reachable
+	// through serveUpload
+	safeUploadDir = "uploads"
+)
+
+// imageTypes are the content types the safe upload accepts, with the
+// extension it stores them under
+var imageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// uploadFile reads the file field of a multipart form, up to maxUploadSize
+func uploadFile(c *gin.Context) (name, contentType string, data []byte, err error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize+64<<10)
+	header, err := c.FormFile("file")
+	if err != nil {
+		return "", "", nil, err
+	}
+	if header.Size > maxUploadSize {
+		return "", "", nil, fmt.Errorf("file is larger than %d bytes", maxUploadSize)
+	}
+	f, err := header.Open()
+	if err != nil {
+		return "", "", nil, err
+	}
+	defer f.Close()
+	data, err = io.ReadAll(f)
+	return header.Filename, header.Header.Get("Content-Type"), data, err
+}
+
+// Unsafe upload - trusts the client's file name and content type
+func unsafeUpload(c *gin.Context) {
+	original, contentType, data, err := uploadFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Upload failed: %v", err)})
+		return
+	}
+
+	// Unsafe: "images only" is checked against the Content-Type the
+	// client declared, and image/svg+xml is an image that runs scripts
+	if !strings.HasPrefix(contentType, "image/") {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Only images are allowed, got " + contentType})
+		return
+	}
+	// Unsafe: the client's name is kept, so its extension decides the
+	// Content-Type the file is served with. Only the directory part is
+	// dropped, which keeps the file inside the upload directory.
+	name := path.Base(strings.ReplaceAll(original, `\`, "/"))
+	if name == "." || name == ".." || name == "/" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid file name"})
+		return
+	}
+
+	if err := os.MkdirAll(unsafeUploadDir, 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Upload failed: %v", err)})
+		return
+	}
+	if err := os.WriteFile(filepath.Join(unsafeUploadDir, name), data, 0o644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Upload failed: %v", err)})
+		return
+	}
+	db.Create(&Upload{Name: name, Original: original, ContentType: contentType, Size: int64(len(data)), Mode: uploadUnsafe, Uploader: commentAuthor(c)})
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+// Safe upload - checks what the file is, and names and serves it itself
+func safeUpload(c *gin.Context) {
+	original, _, data, err := uploadFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Upload failed: %v", err)})
+		return
+	}
+
+	// Safe: the type is sniffed from the content, and the image must
+	// decode as that type. SVG sniffs as text and is rejected.
+	contentType := http.DetectContentType(data)
+	ext, ok := imageTypes[contentType]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Only PNG, JPEG and GIF images are allowed, got " + contentType})
+		return
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err != nil || "image/"+format != contentType {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "File is not a valid " + contentType + " image"})
+		return
+	}
+
+	// Safe: a random name with the extension of the detected type
+	b := make([]byte, 16)
+	rand.Read(b)
+	name := hex.EncodeToString(b) + ext
+
+	if err := os.MkdirAll(safeUploadDir, 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Upload failed: %v", err)})
+		return
+	}
+	if err := os.WriteFile(filepath.Join(safeUploadDir, name), data, 0o644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Upload failed: %v", err)})
+		return
+	}
+	db.Create(&Upload{Name: name, Original: original, ContentType: contentType, Size: int64(len(data)), Mode: uploadSafe, Uploader: commentAuthor(c)})
+	c.Redirect(http.StatusFound, layout.BasePath(c))
+}
+
+// serveUpload serves a safe upload with headers that keep it from being
+// rendered as a page of this origin
+func serveUpload(c *gin.Context) {
+	var upload Upload
+	if err := db.First(&upload, "name = ? AND mode = ?", c.Param("name"), uploadSafe).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "File not found"})
+		return
+	}
+	h := c.Writer.Header()
+	// Safe: the stored, detected type, and no sniffing a different one
+	h.Set("Content-Type", upload.ContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	// Safe: opening the URL downloads the file; <img> still displays it
+	h.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", upload.Name))
+	// Safe: if it is rendered anyway, it runs in a sandbox with a unique
+	// origin and no scripts. A separate domain for user content does the
+	// same for browsers that ignore these headers.
+	h.Set("Content-Security-Policy", "default-src 'none'; img-src 'self'; sandbox")
+	c.File(filepath.Join(safeUploadDir, upload.Name))
+}
+
+// uploadList returns the uploads of one mode, newest first
+func uploadList(mode string) []Upload {
+	var uploads []Upload
+	db.Where("mode = ?", mode).Order("id desc").Limit(commentsPerPage).Find(&uploads)
+	return uploads
+}
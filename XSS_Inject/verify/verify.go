@@ -0,0 +1,326 @@
+// Package verify decides whether injected input would run as script,
+// without a browser. Markup is split with the HTML5 tokenizer, so element
+// and attribute boundaries are the ones a browser would see, and a canary
+// identifier planted in the payload is looked for in places that execute:
+// script elements, event handler attributes and javascript: URLs.
+//
+// Within script code the canary only counts outside string literals and
+// comments, so a payload that was escaped into a string is reported as
+// harmless. The JavaScript scanner does not understand regular expression
+// literals, and whether a CSP would block the script is not considered.
+//
+// Scan does the same without a canary: it reports all script in a page
+// and every URL the page would load on its own, for callers that want to
+// know what a page does rather than whether one payload got through.
+package verify
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Execution contexts reported in findings
+const (
+	ScriptElement = "script-element"
+	EventHandler  = "event-handler"
+	JavaScriptURL = "javascript-url"
+	Subresource   = "subresource" // a URL loaded without user interaction, Scan only
+)
+
+// maxSrcdocDepth bounds the recursion into nested srcdoc documents
+const maxSrcdocDepth = 3
+
+// SectionAttr marks the parts of a page. Each finding records the value
+// of the nearest preceding element with this attribute.
+const SectionAttr = "data-section"
+
+// Finding is one place where the canary would run
+type Finding struct {
+	Context string // ScriptElement, EventHandler, JavaScriptURL or Subresource
+	Tag     string
+	Attr    string // empty for script element content
+	Code    string // the script, handler or URL
+	Section string // see SectionAttr
+}
+
+// urlAttrs are attributes whose javascript: URLs run when followed
+var urlAttrs = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"action":     true,
+	"formaction": true,
+	"data":       true,
+	"xlink:href": true,
+}
+
+// loadAttrs are the attributes each element fetches as soon as it is parsed
+var loadAttrs = map[string]string{
+	"img":    "src",
+	"script": "src",
+	"iframe": "src",
+	"frame":  "src",
+	"embed":  "src",
+	"object": "data",
+	"audio":  "src",
+	"video":  "src",
+	"source": "src",
+	"track":  "src",
+	"input":  "src",
+	"link":   "href",
+	"body":   "background",
+}
+
+// scanner holds the state of one pass over a document
+type scanner struct {
+	match    func(code string) bool // reports whether code is of interest
+	requests bool                   // also report subresources
+	section  string
+	findings []Finding
+}
+
+// Analyze returns every executable context of markup that calls canary
+func Analyze(markup, canary string) []Finding {
+	s := &scanner{match: func(code string) bool { return CodeContains(code, canary) }}
+	s.scan(markup, 0)
+	return s.findings
+}
+
+// Scan returns every script, event handler and javascript: URL in markup,
+// and every subresource it would load
+func Scan(markup string) []Finding {
+	s := &scanner{
+		match:    func(code string) bool { return strings.TrimSpace(code) != "" },
+		requests: true,
+	}
+	s.scan(markup, 0)
+	return s.findings
+}
+
+// Exploitable reports whether canary would run anywhere in markup
+func Exploitable(markup, canary string) bool {
+	return len(Analyze(markup, canary)) > 0
+}
+
+func (s *scanner) add(f Finding) {
+	f.Section = s.section
+	s.findings = append(s.findings, f)
+}
+
+func (s *scanner) scan(markup string, depth int) {
+	z := html.NewTokenizer(strings.NewReader(markup))
+	script := "" // name of the open script element, if its content runs
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if section := attr(tok, SectionAttr); section != "" {
+				s.section = section
+			}
+			script = ""
+			// <script/> is a start tag too; HTML ignores the slash
+			if tok.Data == "script" && isJavaScriptType(attr(tok, "type")) {
+				script = tok.Data
+			}
+			s.scanAttrs(tok, depth)
+
+		case html.TextToken:
+			if script != "" {
+				code := string(z.Text())
+				if s.match(code) {
+					s.add(Finding{Context: ScriptElement, Tag: script, Code: code})
+				}
+			}
+
+		case html.EndTagToken:
+			script = ""
+		}
+	}
+}
+
+func (s *scanner) scanAttrs(tok html.Token, depth int) {
+	seen := map[string]bool{}
+	for _, a := range tok.Attr {
+		name := a.Key
+		if a.Namespace != "" {
+			name = a.Namespace + ":" + a.Key
+		}
+		// Browsers keep the first of duplicate attributes
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch {
+		case strings.HasPrefix(name, "on"):
+			if s.match(a.Val) {
+				s.add(Finding{Context: EventHandler, Tag: tok.Data, Attr: name, Code: a.Val})
+			}
+		case name == "srcdoc" && tok.Data == "iframe":
+			// The attribute is a whole same-origin document
+			if depth < maxSrcdocDepth {
+				s.scan(a.Val, depth+1)
+			}
+		default:
+			if code, ok := javaScriptURL(a.Val); ok {
+				if urlAttrs[name] && s.match(code) {
+					s.add(Finding{Context: JavaScriptURL, Tag: tok.Data, Attr: name, Code: a.Val})
+				}
+			} else if s.requests && loadAttrs[tok.Data] == name && strings.TrimSpace(a.Val) != "" {
+				s.add(Finding{Context: Subresource, Tag: tok.Data, Attr: name, Code: strings.TrimSpace(a.Val)})
+			}
+		}
+	}
+}
+
+func attr(tok html.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Namespace == "" && a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// isJavaScriptType reports whether a script element with this type
+// attribute is executed
+func isJavaScriptType(typ string) bool {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	switch typ {
+	case "", "module", "text/javascript", "application/javascript", "text/ecmascript", "application/ecmascript":
+		return true
+	}
+	return false
+}
+
+// javaScriptURL returns the code of a javascript: URL. The tokenizer has
+// already decoded character references; browsers also drop tabs and
+// newlines and leading control characters and spaces, and percent-decode
+// the code.
+func javaScriptURL(raw string) (string, bool) {
+	cleaned := strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, raw)
+	cleaned = strings.TrimLeftFunc(cleaned, func(r rune) bool { return r <= ' ' })
+
+	const scheme = "javascript:"
+	if len(cleaned) < len(scheme) || !strings.EqualFold(cleaned[:len(scheme)], scheme) {
+		return "", false
+	}
+	code := cleaned[len(scheme):]
+	if decoded, err := url.PathUnescape(code); err == nil {
+		code = decoded
+	}
+	return code, true
+}
+
+// CodeContains reports whether the identifier canary appears in JavaScript
+// source outside string literals, template literal text and comments
+func CodeContains(src, canary string) bool {
+	if canary == "" {
+		return false
+	}
+	// braces counts the open braces of each ${...} substitution
+	var braces []int
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipString(src, i+1, c)
+		case c == '`':
+			var subst bool
+			if i, subst = skipTemplate(src, i+1); subst {
+				braces = append(braces, 0)
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			i = skipUntil(src, i+2, "\n")
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			i = skipUntil(src, i+2, "*/")
+		case c == '{' && len(braces) > 0:
+			braces[len(braces)-1]++
+			i++
+		case c == '}' && len(braces) > 0:
+			if braces[len(braces)-1] > 0 {
+				braces[len(braces)-1]--
+				i++
+				continue
+			}
+			// End of a substitution: back inside the template literal
+			braces = braces[:len(braces)-1]
+			var subst bool
+			if i, subst = skipTemplate(src, i+1); subst {
+				braces = append(braces, 0)
+			}
+		case isIdentStart(c):
+			j := i
+			for j < len(src) && isIdentPart(src[j]) {
+				j++
+			}
+			if src[i:j] == canary {
+				return true
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return false
+}
+
+// skipString returns the index after the closing quote
+func skipString(src string, i int, quote byte) int {
+	for i < len(src) {
+		switch src[i] {
+		case '\\':
+			i += 2
+		case quote, '\n':
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return i
+}
+
+// skipTemplate skips template literal text up to the closing backtick or
+// the start of a ${ substitution, which is code, and returns the index
+// after it
+func skipTemplate(src string, i int) (int, bool) {
+	for i < len(src) {
+		switch {
+		case src[i] == '\\':
+			i += 2
+		case src[i] == '`':
+			return i + 1, false
+		case src[i] == '$' && i+1 < len(src) && src[i+1] == '{':
+			return i + 2, true
+		default:
+			i++
+		}
+	}
+	return i, false
+}
+
+func skipUntil(src string, i int, end string) int {
+	if j := strings.Index(src[i:], end); j >= 0 {
+		return i + j + len(end)
+	}
+	return len(src)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}